@@ -0,0 +1,260 @@
+// Command deptree validates, orders and renders dependency trees declared in
+// JSON or YAML files.
+//
+// Usage:
+//
+//	deptree validate <file>
+//	deptree order <file>
+//	deptree layers <file>
+//	deptree render [--format ascii|dot|mermaid|json] <file>
+//	deptree why [--all] <file> <item> <dependency>
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cjlapao/common-go-dependency-tree/dependencytree"
+)
+
+const usage = `Usage: deptree <command> [flags] <file> [args]
+
+Commands:
+  validate <file>                   report every problem found in the graph
+  order <file>                      print the items in the order returned by Build
+  layers <file>                     print the items grouped in layers that can run in parallel
+  render [--format f] <file>        render the graph as ascii, dot, mermaid or json
+  why [--all] <file> <item> <dep>   explain how an item depends on another one
+
+Files ending in .yaml or .yml are read as YAML, any other file as JSON.
+`
+
+// errUsage is returned when the command line is not valid, the usage is
+// printed instead of the error.
+var errUsage = errors.New("invalid usage")
+
+type service = dependencytree.DependencyTreeService[interface{}]
+
+type command func(args []string, stdout io.Writer) error
+
+var commands = map[string]command{
+	"validate": validate,
+	"order":    order,
+	"layers":   layers,
+	"render":   render,
+	"why":      why,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %s\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := cmd(args[1:], stdout); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+
+		fmt.Fprintf(stderr, "deptree: %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// load reads the graph file, using the extension to pick the format.
+func load(path string) (*service, error) {
+	tree := dependencytree.New[interface{}]()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := tree.LoadYAMLFile(path); err != nil {
+			return nil, err
+		}
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		if err := tree.LoadJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return tree, nil
+}
+
+// loadArgs parses the flags and loads the file given as the first argument,
+// returning the remaining arguments.
+func loadArgs(flags *flag.FlagSet, args []string, expected int) (*service, []string, error) {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, errUsage
+	}
+	if flags.NArg() != expected+1 {
+		return nil, nil, errUsage
+	}
+
+	tree, err := load(flags.Arg(0))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tree, flags.Args()[1:], nil
+}
+
+func validate(args []string, stdout io.Writer) error {
+	tree, _, err := loadArgs(flag.NewFlagSet("validate", flag.ContinueOnError), args, 0)
+	if err != nil {
+		return err
+	}
+
+	diagnostics := tree.Validate()
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(stdout, diagnostic.String())
+	}
+
+	if diagnostics.HasErrors() {
+		return errors.New("validation failed")
+	}
+
+	if len(diagnostics) == 0 {
+		fmt.Fprintln(stdout, "no problems found")
+	}
+
+	return nil
+}
+
+func order(args []string, stdout io.Writer) error {
+	tree, _, err := loadArgs(flag.NewFlagSet("order", flag.ContinueOnError), args, 0)
+	if err != nil {
+		return err
+	}
+
+	items, err := tree.Build()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		fmt.Fprintln(stdout, item.ID)
+	}
+
+	return nil
+}
+
+func layers(args []string, stdout io.Writer) error {
+	tree, _, err := loadArgs(flag.NewFlagSet("layers", flag.ContinueOnError), args, 0)
+	if err != nil {
+		return err
+	}
+
+	result, err := tree.BuildLayers()
+	if err != nil {
+		return err
+	}
+
+	for idx, layer := range result {
+		ids := make([]string, 0, len(layer))
+		for _, item := range layer {
+			ids = append(ids, item.ID)
+		}
+		fmt.Fprintf(stdout, "%d: %s\n", idx, strings.Join(ids, ", "))
+	}
+
+	return nil
+}
+
+func render(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := flags.String("format", "ascii", "ascii, dot, mermaid or json")
+	tree, _, err := loadArgs(flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "ascii":
+		if _, err := tree.Build(); err != nil {
+			return err
+		}
+		return tree.Render(stdout, dependencytree.RenderOptions{ASCII: true})
+	case "dot":
+		return tree.WriteDOT(stdout, dependencytree.DOTOptions{})
+	case "mermaid":
+		return tree.WriteMermaid(stdout, dependencytree.MermaidOptions{})
+	case "json":
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, string(data))
+		return err
+	default:
+		return fmt.Errorf("unknown format %s, expected ascii, dot, mermaid or json", *format)
+	}
+}
+
+func why(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("why", flag.ContinueOnError)
+	all := flags.Bool("all", false, "print every path instead of the shortest one")
+	tree, rest, err := loadArgs(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	// Paths walks every path, which grows quickly in dense graphs, so it is
+	// only used when every path was asked for
+	if !*all {
+		path, err := tree.ShortestPath(rest[0], rest[1])
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			return fmt.Errorf("%s does not depend on %s", rest[0], rest[1])
+		}
+
+		fmt.Fprintf(stdout, "%s comes after %s because %s\n", path[0].From.ID, path[len(path)-1].To.ID, path.String())
+		return nil
+	}
+
+	paths, err := tree.Paths(rest[0], rest[1])
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s does not depend on %s", rest[0], rest[1])
+	}
+
+	from := paths[0][0].From.ID
+	to := paths[0][len(paths[0])-1].To.ID
+	fmt.Fprintf(stdout, "%s comes after %s because of %d paths:\n", from, to, len(paths))
+	for _, path := range paths {
+		fmt.Fprintf(stdout, "  %s\n", path.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGraph = `version: 1
+items:
+  - id: database
+    name: Database
+  - id: cache
+    name: Cache
+  - id: api
+    name: API
+    dependsOn: [database, cache]
+  - id: handlers
+    name: Handlers
+    parent: api
+  - id: gateway
+    name: Gateway
+    dependsOn: [handlers, api]
+`
+
+func writeGraph(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func runCommand(args ...string) (int, string, string) {
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Prints the usage", func(t *testing.T) {
+		code, _, stderr := runCommand()
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "Usage: deptree")
+
+		code, stdout, _ := runCommand("help")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "Usage: deptree")
+
+		code, _, stderr = runCommand("unknown")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "unknown command unknown")
+
+		code, _, stderr = runCommand("order")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "Usage: deptree")
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		code, stdout, _ := runCommand("validate", writeGraph(t, "graph.yaml", testGraph))
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "[warning] redundant-dependency: dependency on api of item gateway is redundant, it is already required through gateway -> handlers -> api\n", stdout)
+
+		invalid := writeGraph(t, "graph.json", `{"version": 1, "items": [{"id": "a", "name": "a", "dependsOn": ["b"]}]}`)
+		code, stdout, stderr := runCommand("validate", invalid)
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "[error] missing-dependency: dependency on b of item a was not found\n", stdout)
+		assert.Equal(t, "deptree: validation failed\n", stderr)
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		code, stdout, _ := runCommand("order", writeGraph(t, "graph.yml", testGraph))
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "database\ncache\napi\nhandlers\ngateway\n", stdout)
+	})
+
+	t.Run("Layers", func(t *testing.T) {
+		code, stdout, _ := runCommand("layers", writeGraph(t, "graph.yaml", testGraph))
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "0: database, cache\n1: api\n2: handlers\n3: gateway\n", stdout)
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		path := writeGraph(t, "graph.yaml", testGraph)
+
+		code, stdout, _ := runCommand("render", path)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "+- Database\n+- Cache\n+- API\n|  \\- Handlers\n\\- Gateway\n", stdout)
+
+		code, stdout, _ = runCommand("render", "--format", "dot", path)
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, `"api" -> "database";`)
+
+		code, stdout, _ = runCommand("render", "--format", "mermaid", path)
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "n_api --> n_cache")
+
+		code, stdout, _ = runCommand("render", "--format", "json", path)
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, `"version": 1`)
+
+		code, _, stderr := runCommand("render", "--format", "svg", path)
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "deptree: unknown format svg, expected ascii, dot, mermaid or json\n", stderr)
+	})
+
+	t.Run("Why", func(t *testing.T) {
+		path := writeGraph(t, "graph.yaml", testGraph)
+
+		code, stdout, _ := runCommand("why", path, "handlers", "database")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "handlers comes after database because handlers -> parent api -> database\n", stdout)
+
+		code, stdout, _ = runCommand("why", "--all", path, "gateway", "database")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "gateway comes after database because of 2 paths:\n  gateway -> api -> database\n  gateway -> handlers -> parent api -> database\n", stdout)
+
+		code, _, stderr := runCommand("why", path, "database", "api")
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "deptree: database does not depend on api\n", stderr)
+
+		code, _, stderr = runCommand("why", path, "api", "missing")
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "deptree: item with id missing not found\n", stderr)
+	})
+
+	t.Run("Reports load errors", func(t *testing.T) {
+		path := writeGraph(t, "graph.yaml", "version: 1\nitems:\n  - id: a\n")
+
+		code, _, stderr := runCommand("order", path)
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "deptree: "+path+": line 3: item 0 is not valid: name must not be empty\n", stderr)
+	})
+}
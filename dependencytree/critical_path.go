@@ -0,0 +1,115 @@
+package dependencytree
+
+import (
+	"fmt"
+	"time"
+)
+
+// DurationProperty is the metadata key holding the estimated duration of an
+// item used by CriticalPath, it accepts a time.Duration or a duration string.
+const DurationProperty = "duration"
+
+// DurationFunc returns the estimated duration of an item.
+type DurationFunc[T interface{}] func(item *DependencyTreeItem[T]) time.Duration
+
+// CriticalPathReport is the result of CriticalPath.
+type CriticalPathReport[T interface{}] struct {
+	// Path is the longest chain of items, in the order they run.
+	Path []*DependencyTreeItem[T]
+	// Total is the time it takes to run every item when each one starts as
+	// soon as the items it depends on have finished.
+	Total time.Duration
+	// Slack is how much every item, by id, can be delayed without delaying
+	// the total, the items of the path have no slack.
+	Slack map[string]time.Duration
+}
+
+// CriticalPath builds the tree and returns the chain of items that decides how
+// long it takes to run all of them, following every edge Build orders the items
+// by. The durations come from the function, or from the DurationProperty
+// metadata when it is nil, items without a duration take no time.
+func (d *DependencyTreeService[T]) CriticalPath(duration DurationFunc[T]) (*CriticalPathReport[T], error) {
+	graph, callbacks, err := d.buildGraph()
+	if err != nil {
+		return nil, err
+	}
+	runCallbacks(callbacks)
+
+	durations := make([]time.Duration, len(graph.items))
+	for idx, item := range graph.items {
+		if duration != nil {
+			durations[idx] = duration(item)
+			continue
+		}
+
+		if durations[idx], err = durationOf(item); err != nil {
+			return nil, err
+		}
+	}
+
+	// the items of the graph are in build order, so the dependencies of an
+	// item always finish before the item starts
+	finish := make([]time.Duration, len(graph.items))
+	previous := make([]int, len(graph.items))
+	last := -1
+	for idx := range graph.items {
+		previous[idx] = -1
+		for _, dependency := range graph.dependencies[idx] {
+			if previous[idx] == -1 || finish[dependency] > finish[previous[idx]] {
+				previous[idx] = dependency
+			}
+		}
+
+		if previous[idx] != -1 {
+			finish[idx] = finish[previous[idx]]
+		}
+		finish[idx] += durations[idx]
+
+		if last == -1 || finish[idx] > finish[last] {
+			last = idx
+		}
+	}
+
+	report := CriticalPathReport[T]{
+		Path:  []*DependencyTreeItem[T]{},
+		Slack: make(map[string]time.Duration, len(graph.items)),
+	}
+	if last == -1 {
+		return &report, nil
+	}
+	report.Total = finish[last]
+
+	latestFinish := make([]time.Duration, len(graph.items))
+	for idx := len(graph.items) - 1; idx >= 0; idx-- {
+		latestFinish[idx] = report.Total
+		for _, dependent := range graph.dependents[idx] {
+			if start := latestFinish[dependent] - durations[dependent]; start < latestFinish[idx] {
+				latestFinish[idx] = start
+			}
+		}
+		report.Slack[graph.items[idx].ID] = latestFinish[idx] - finish[idx]
+	}
+
+	for idx := last; idx != -1; idx = previous[idx] {
+		report.Path = append([]*DependencyTreeItem[T]{graph.items[idx]}, report.Path...)
+	}
+
+	return &report, nil
+}
+
+func durationOf[T interface{}](item *DependencyTreeItem[T]) (time.Duration, error) {
+	switch value := item.GetProperty(DurationProperty, nil).(type) {
+	case nil:
+		return 0, nil
+	case time.Duration:
+		return value, nil
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s of item %s: %w", value, item.ID, err)
+		}
+		return duration, nil
+	default:
+		return 0, fmt.Errorf("invalid duration %v of item %s", value, item.ID)
+	}
+}
@@ -0,0 +1,131 @@
+package dependencytree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCriticalPath(t *testing.T) {
+	t.Run("Uses the durations from the metadata", func(t *testing.T) {
+		service := New[MockObject1]()
+		durations := map[string]interface{}{
+			"config":   "1s",
+			"database": 3 * time.Second,
+			"cache":    "1s",
+			"api":      "2s",
+			"handlers": "1s",
+			"metrics":  "1s",
+		}
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		for id, duration := range durations {
+			_ = service.GetItem(id).SetProperty(DurationProperty, duration)
+		}
+		report, err := service.CriticalPath(nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "api", "handlers"}, itemIDs(report.Path))
+		assert.Equal(t, 7*time.Second, report.Total)
+		assert.Equal(t, map[string]time.Duration{
+			"config":   0,
+			"database": 0,
+			"cache":    2 * time.Second,
+			"api":      0,
+			"handlers": 0,
+			"metrics":  6 * time.Second,
+		}, report.Slack)
+	})
+
+	t.Run("Uses the durations from the function", func(t *testing.T) {
+		service := New[MockObject1]()
+		durations := map[string]interface{}{
+			"config":   "1s",
+			"database": 3 * time.Second,
+			"cache":    "1s",
+			"api":      "2s",
+			"handlers": "1s",
+			"metrics":  "1s",
+		}
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		for id, duration := range durations {
+			_ = service.GetItem(id).SetProperty(DurationProperty, duration)
+		}
+		report, err := service.CriticalPath(func(item *DependencyTreeItem[MockObject1]) time.Duration {
+			if item.ID == "metrics" {
+				return 10 * time.Second
+			}
+			return time.Second
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"metrics"}, itemIDs(report.Path))
+		assert.Equal(t, 10*time.Second, report.Total)
+		assert.Equal(t, 6*time.Second, report.Slack["cache"])
+	})
+
+	t.Run("Empty tree", func(t *testing.T) {
+		report, err := New[MockObject1]().CriticalPath(nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, report.Path)
+		assert.Zero(t, report.Total)
+	})
+
+	t.Run("Returns errors", func(t *testing.T) {
+		service := New[MockObject1]()
+		durations := map[string]interface{}{
+			"config":   "1s",
+			"database": 3 * time.Second,
+			"cache":    "1s",
+			"api":      "2s",
+			"handlers": "1s",
+			"metrics":  "1s",
+		}
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		for id, duration := range durations {
+			_ = service.GetItem(id).SetProperty(DurationProperty, duration)
+		}
+		_ = service.GetItem("api").SetProperty(DurationProperty, "soon")
+
+		_, err := service.CriticalPath(nil)
+		assert.EqualError(t, err, "invalid duration soon of item api: time: invalid duration \"soon\"")
+
+		_ = service.GetItem("api").SetProperty(DurationProperty, 5)
+		_, err = service.CriticalPath(nil)
+		assert.EqualError(t, err, "invalid duration 5 of item api")
+
+		_ = service.DependsOn("config", "api")
+		_, err = service.CriticalPath(nil)
+		var cycleErr *CycleError
+		assert.ErrorAs(t, err, &cycleErr)
+	})
+}
@@ -0,0 +1,36 @@
+package dependencytree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleItem identifies one item of a dependency cycle.
+type CycleItem struct {
+	ID   string
+	Name string
+}
+
+func (c CycleItem) String() string {
+	return fmt.Sprintf("%s (%s)", c.ID, c.Name)
+}
+
+// CycleError is returned by Build when the dependencies are circular. Every
+// group of items that depend on each other is reported with the shortest cycle
+// found in it, starting and ending on the same item, for example A -> B -> A.
+type CycleError struct {
+	Cycles [][]CycleItem
+}
+
+func (e *CycleError) Error() string {
+	cycles := make([]string, 0, len(e.Cycles))
+	for _, cycle := range e.Cycles {
+		path := make([]string, 0, len(cycle))
+		for _, item := range cycle {
+			path = append(path, item.String())
+		}
+		cycles = append(cycles, strings.Join(path, " -> "))
+	}
+
+	return fmt.Sprintf("circular dependency detected: %s", strings.Join(cycles, "; "))
+}
@@ -0,0 +1,30 @@
+package dependencytree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCycleErrorString(t *testing.T) {
+	t.Run("Single cycle", func(t *testing.T) {
+		err := &CycleError{
+			Cycles: [][]CycleItem{
+				{{"a", "Item A"}, {"b", "Item B"}, {"a", "Item A"}},
+			},
+		}
+
+		assert.Equal(t, "circular dependency detected: a (Item A) -> b (Item B) -> a (Item A)", err.Error())
+	})
+
+	t.Run("Multiple cycles", func(t *testing.T) {
+		err := &CycleError{
+			Cycles: [][]CycleItem{
+				{{"a", "a"}, {"b", "b"}, {"a", "a"}},
+				{{"c", "c"}, {"c", "c"}},
+			},
+		}
+
+		assert.Equal(t, "circular dependency detected: a (a) -> b (b) -> a (a); c (c) -> c (c)", err.Error())
+	})
+}
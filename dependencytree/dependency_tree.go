@@ -1,315 +1,464 @@
 package dependencytree
 
 import (
+	"container/heap"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 func (d *DependencyTreeService[T]) Build() ([]*DependencyTreeItem[T], error) {
+	d.mu.Lock()
+	values, callbacks, err := d.build()
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	runCallbacks(callbacks)
+	return values, nil
+}
+
+// build orders the flat tree and rebuilds the tree, returning the callbacks to
+// run once the lock is released. The caller must hold the write lock.
+func (d *DependencyTreeService[T]) build() ([]*DependencyTreeItem[T], []func(), error) {
+	logger := d.getLogger()
 	if d.IsDebug() {
-		d.logger.Debug("Dependency Tree Before:")
+		logger.Debug("Dependency Tree Before:")
 		for idx, service := range d.flatTree {
-			d.logger.Debug("[%s] %s", strconv.Itoa(idx), service.Name)
+			logger.Debug("[%s] %s", strconv.Itoa(idx), service.Name)
 		}
 	}
 
-	// Expanding the tree to include the parent and children
-	if err := d.expandFlatTree(); err != nil {
-		return nil, err
+	if err := d.checkParents(); err != nil {
+		return nil, nil, err
 	}
 
-	// Initial Pass to flatten our dependency tree based on linear dependency
-	values, err := d.buildRightDependency()
-	if err != nil {
-		return values, err
-	}
+	// reindex replaces the positions, so these stay as they were
+	previous := d.positions
 
-	// Reordering dependencies for stragglers (left shift)
-	// First pass we ordered all of the dependencies in a forward method
-	// putting it all in an order of a -> b -> c kind of dependency
-	// we also might have services that might need sifting as they didn't fall into this like
-	// a late service that has only dependency on b but not on c
-	// }
-
-	// Building parent dependency
-	err = d.buildChildDependency()
-	if err != nil {
-		return values, err
-	}
+	// Expanding the tree to include the parent and children
+	regrouped := d.expandFlatTree()
 
-	// Last pass to make sure the left dependency did not make further issues
-	values, err = d.buildRightDependency()
+	// Ordering the items so every item comes after its dependencies and
+	// children are kept right after their parent whenever possible
+	values, err := d.sortFlatTree(regrouped)
 	if err != nil {
-		return values, err
+		// the new children of this build would not be regrouped by the next
+		// one, so it sorts every item again
+		d.sorted = false
+		return nil, nil, err
 	}
+	d.flatTree = values
+	d.reindex()
+	callbacks := d.callbacks(previous)
 
-	tree := d.buildTree("root")
+	tree := d.buildTree()
 	d.tree = tree
+	d.sorted = true
+	d.builtVersion = d.version
 
 	if d.IsDebug() && d.IsVerbose() {
-		d.logger.Debug(d.String())
+		logger.Debug(d.string())
 	}
 
 	if d.IsDebug() {
-		d.logger.Debug("Dependency Tree After:")
+		logger.Debug("Dependency Tree After:")
 		for idx, service := range values {
-			d.logger.Debug("[%s] %s", strconv.Itoa(idx), service.Name)
+			logger.Debug("[%s] %s", strconv.Itoa(idx), service.Name)
 		}
 	}
 
-	return values, nil
+	return values, callbacks, nil
 }
 
-func (d *DependencyTreeService[T]) expandFlatTree() error {
-	for _, item := range d.flatTree {
-		if item.GetParentName() == "" || item.GetParentName() == "root" {
-			d.printVerbosef("Item %s is a root item", item.Name)
-			continue
-		}
+// buildGraph builds the tree and returns a snapshot of its dependency graph,
+// together with the callbacks to run once the lock is released.
+func (d *DependencyTreeService[T]) buildGraph() (*dependencyGraph[T], []func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-		parent := d.GetItem(item.GetParentName())
-		if parent == nil {
-			d.printVerbosef("Could not find ttem %s parent, ignoring it", item.Name)
-		} else {
-			d.printVerbosef("Item %s has a parent %s", item.Name, parent.Name)
-		}
+	_, callbacks, err := d.build()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		item.parentName = parent.ID
-		item.Parent = parent
-		err := item.DependsOn(parent.ID)
-		if err != nil {
-			return err
-		}
-		parent.AddChild(item)
+	graph, err := d.newDependencyGraph()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	return graph, callbacks, nil
 }
 
-func (d *DependencyTreeService[T]) buildTree(parent string) []*DependencyTreeItem[T] {
-	result := []*DependencyTreeItem[T]{}
-	childs := d.GetItemByParent(parent)
-	for _, child := range childs {
-		if len(child.Children) > 0 {
-			children := d.buildTree(child.Name)
-			child.Children = children
+// callbacks returns the callback of every child item the build moved, in the
+// order the items were placed, so building a tree that is already in order
+// does not call any of them. The caller must hold the lock, as a concurrent
+// Build changes the parents of the items.
+func (d *DependencyTreeService[T]) callbacks(previous map[*DependencyTreeItem[T]]int) []func() {
+	result := []func(){}
+	for idx, item := range d.flatTree {
+		if position, ok := previous[item]; ok && position == idx {
+			continue
+		}
+		if len(item.Parents) > 0 && item.CallBack != nil {
+			result = append(result, item.CallBack)
 		}
-		result = append(result, child)
 	}
 
 	return result
 }
 
-func (d *DependencyTreeService[T]) buildChildDependency() error {
-	idx := 0
-parentLoop:
-	for {
-		shiftedItem := false
-	treeLoop:
-		for idx, item := range d.flatTree {
-			if len(item.Children) == 0 {
-				continue treeLoop
+// runCallbacks runs the callbacks without holding the lock so they can use the
+// service.
+func runCallbacks(callbacks []func()) {
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// BuildLayers builds the tree and groups the items in stages, where every item
+// only depends on items of earlier stages. Items in the same stage do not depend
+// on each other and can be started at the same time.
+func (d *DependencyTreeService[T]) BuildLayers() ([][]*DependencyTreeItem[T], error) {
+	graph, callbacks, err := d.buildGraph()
+	if err != nil {
+		return nil, err
+	}
+	runCallbacks(callbacks)
+
+	// the flat tree is now sorted, so the dependencies of an item always
+	// have their layer calculated before the item itself
+	layers := [][]*DependencyTreeItem[T]{}
+	itemLayers := make([]int, len(graph.items))
+	for idx, item := range graph.items {
+		layer := 0
+		for _, dependency := range graph.dependencies[idx] {
+			if itemLayers[dependency]+1 > layer {
+				layer = itemLayers[dependency] + 1
+			}
+		}
+
+		itemLayers[idx] = layer
+		if layer == len(layers) {
+			layers = append(layers, []*DependencyTreeItem[T]{})
+		}
+		layers[layer] = append(layers[layer], item)
+	}
+
+	if d.IsDebug() {
+		logger := d.getLogger()
+		logger.Debug("Dependency Tree Layers:")
+		for idx, layer := range layers {
+			names := make([]string, 0, len(layer))
+			for _, item := range layer {
+				names = append(names, item.Name)
 			}
+			logger.Debug("[%s] %s", strconv.Itoa(idx), strings.Join(names, ", "))
+		}
+	}
+
+	return layers, nil
+}
+
+// BuildTeardown builds the tree and returns the items in the order they should
+// be stopped, the reverse of Build, so every item comes after the items that
+// require it and children come before their parent.
+func (d *DependencyTreeService[T]) BuildTeardown() ([]*DependencyTreeItem[T], error) {
+	values, err := d.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DependencyTreeItem[T], len(values))
+	for idx, item := range values {
+		result[len(values)-1-idx] = item
+	}
+
+	return result, nil
+}
 
-			_, err := d.shiftChildItems(idx)
-			if err != nil {
-				return err
+// expandFlatTree links every item to its parents and returns the items that
+// were added to the group of a parent since the last build.
+func (d *DependencyTreeService[T]) expandFlatTree() []*DependencyTreeItem[T] {
+	regrouped := []*DependencyTreeItem[T]{}
+	for _, item := range d.flatTree {
+		if item.GetParentName() == "" || item.GetParentName() == "root" {
+			d.printVerbosef("Item %s is a root item", item.Name)
+		} else if parent := d.getItem(item.GetParentName()); parent == nil {
+			d.printVerbosef("Could not find item %s parent, ignoring it", item.Name)
+		} else {
+			d.printVerbosef("Item %s has a parent %s", item.Name, parent.Name)
+			item.parentName = parent.ID
+			item.Parent = parent
+			if d.expandParent(item, parent) {
+				regrouped = append(regrouped, item)
 			}
 		}
 
-		idx += 1
-		if !shiftedItem || idx > 1000 {
-			if idx == 1000 {
-				err := fmt.Errorf("something went wrong and we shifted more than 1000 items")
-				return err
+		for _, parentName := range item.AdditionalParents() {
+			parent := d.getItem(parentName)
+			if parent == nil || parent == item {
+				d.printVerbosef("Could not find the additional parent %s of item %s, ignoring it", parentName, item.Name)
+				continue
 			}
 
-			break parentLoop
+			d.printVerbosef("Item %s is shared with parent %s", item.Name, parent.Name)
+			if d.expandParent(item, parent) && !slices.Contains(regrouped, item) {
+				regrouped = append(regrouped, item)
+			}
 		}
 	}
 
-	return nil
-}
+	// Making sure every dependency knows which items require it, as items
+	// can also declare their dependencies directly. Optional dependencies only
+	// change the order, so they are left out
+	requiredBy := make(map[*DependencyTreeItem[T]]map[string]bool)
+	for _, item := range d.flatTree {
+		for _, dependency := range item.IsDependentOn() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil {
+				continue
+			}
 
-func (d *DependencyTreeService[T]) buildRightDependency() ([]*DependencyTreeItem[T], error) {
-	idx := 0
-outerLoop:
-	for {
-		needsShifting := false
-	treeLoop:
-		for svcIndex, item := range d.flatTree {
-			var err error
-			dependencies := item.IsDependentOn()
-			for _, dependency := range dependencies {
-				needsShifting, err = d.buildRightItemDependency(item, dependency, svcIndex)
-				if err != nil {
-					return nil, err
+			known, ok := requiredBy[dependencyItem]
+			if !ok {
+				known = make(map[string]bool, len(dependencyItem.requiredBy))
+				for _, id := range dependencyItem.requiredBy {
+					known[d.key(id)] = true
 				}
+				requiredBy[dependencyItem] = known
 			}
-			if needsShifting {
-				break treeLoop
+
+			if !known[d.key(item.ID)] {
+				known[d.key(item.ID)] = true
+				dependencyItem.requiredBy = append(dependencyItem.requiredBy, item.ID)
 			}
 		}
+	}
 
-		idx += 1
-		if !needsShifting || idx > 1000 {
-			if idx == 1000 {
-				err := fmt.Errorf("something went wrong and we shifted more than 1000 items")
-				return nil, err
-			}
+	return regrouped
+}
+
+// checkParents makes sure every shared item can be placed after all of its
+// parents, which is not possible when it is also an ancestor of one of them.
+func (d *DependencyTreeService[T]) checkParents() error {
+	errs := []error{}
+	for _, item := range d.flatTree {
+		conflicts := []string{}
+		for _, parent := range d.parentConflicts(item) {
+			conflicts = append(conflicts, parent.ID)
+		}
 
-			break outerLoop
+		if len(conflicts) > 0 {
+			errs = append(errs, fmt.Errorf("item %s cannot be placed after all of its parents, it is also an ancestor of %s", item.ID, strings.Join(conflicts, ", ")))
 		}
 	}
 
-	return d.flatTree, nil
+	return errors.Join(errs...)
 }
 
-func (d *DependencyTreeService[T]) buildRightItemDependency(item *DependencyTreeItem[T], dependency string, svcIndex int) (bool, error) {
-	needsShifting := false
-	dependencyIndex, err := d.GetItemIndex(dependency)
-	if err != nil {
-		return false, err
+// expandParent makes the item a child of the parent, it depends on the parent
+// so it is always placed after it. It reports whether the item was not a child
+// of the parent yet.
+func (d *DependencyTreeService[T]) expandParent(item *DependencyTreeItem[T], parent *DependencyTreeItem[T]) bool {
+	for _, known := range item.Parents {
+		if known == parent {
+			// already expanded by a previous build
+			return false
+		}
 	}
 
-	if dependencyIndex < 0 {
-		err := fmt.Errorf("dependency on %s of service %s was not found in the context configuration", dependency, item.Name)
-		return false, err
+	item.Parents = append(item.Parents, parent)
+	// the item might already depend on its parent explicitly
+	if err := item.DependsOn(parent.ID); err != nil {
+		d.printVerbosef("Item %s already depends on its parent %s", item.Name, parent.Name)
+	} else {
+		item.parentDependencies = append(item.parentDependencies, parent.ID)
 	}
+	// the parent is added to requiredBy below, together with the other
+	// dependencies, so we do not pay for AddChild checking it
+	parent.Children = append(parent.Children, item)
+	return true
+}
 
-	if svcIndex < dependencyIndex {
-		needsShifting = true
-		d.printVerbosef("Shifting %s on index %s to index %s", item.Name, strconv.Itoa(svcIndex), strconv.Itoa(dependencyIndex))
-		_, err := d.shiftTo(svcIndex, dependencyIndex)
-		if err != nil {
-			return false, err
+// buildTree returns the root items and sorts the children of every item in the
+// same order as the flat tree, shared items are children of every parent. Items
+// whose parent was not found are root items, like Validate reports them.
+func (d *DependencyTreeService[T]) buildTree() []*DependencyTreeItem[T] {
+	result := []*DependencyTreeItem[T]{}
+	children := make(map[*DependencyTreeItem[T]][]*DependencyTreeItem[T])
+	for _, item := range d.flatTree {
+		for _, parent := range item.Parents {
+			children[parent] = append(children[parent], item)
 		}
 
-		// shifting all of the children to the same position as the parent
-		children := d.GetItemChildren(item.ID)
-		if len(children) == 0 {
-			return false, nil
+		if len(item.Parents) == 0 {
+			result = append(result, item)
 		}
+	}
 
-		currentIndex := 0
-		for {
-			currentIndex, err = d.buildRightItemChildrenItemDependency(item, children, currentIndex, dependencyIndex)
-			if err != nil {
-				return false, err
-			}
-			if currentIndex == len(children) {
-				break
-			}
+	for _, item := range d.flatTree {
+		if len(item.Children) > 0 {
+			item.Children = append([]*DependencyTreeItem[T]{}, children[item]...)
 		}
 	}
 
-	return needsShifting, nil
+	return result
 }
 
-func (d *DependencyTreeService[T]) buildRightItemChildrenItemDependency(item *DependencyTreeItem[T], children []*DependencyTreeItem[T], currentIndex int, dependencyIndex int) (int, error) {
-	childIndex, err := d.GetItemIndex(children[currentIndex].ID)
+// sortFlatTree orders the flat tree using Kahn's algorithm in O((V+E) log V).
+// Ready items are picked by the position of their parent in the output, most
+// recent first, so a parent is followed by its children, and then by their
+// original position so the result is stable and deterministic.
+//
+// Once the tree was built only the range of items between the first and the
+// last item that is out of order is sorted again, the items outside of it keep
+// their position. The range also starts at the parents of the regrouped items,
+// so new children are placed right after their parent like a full sort does.
+func (d *DependencyTreeService[T]) sortFlatTree(regrouped []*DependencyTreeItem[T]) ([]*DependencyTreeItem[T], error) {
+	graph, err := d.newDependencyGraph()
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	if childIndex < 0 {
-		err := fmt.Errorf("dependency on %s of service %s was not found in the context configuration", children[currentIndex].Name, item.Name)
-		return -1, err
+
+	first, last := 0, len(graph.items)-1
+	if d.sorted {
+		first, last = graph.unordered()
+		for _, item := range regrouped {
+			for _, parent := range item.Parents {
+				first = min(first, graph.positions[parent])
+				last = max(last, graph.positions[item])
+			}
+		}
+
+		// items with a parent are picked before root items once they are
+		// ready, so the ones that depend on the range are sorted again too
+		for idx := first; idx <= last; idx++ {
+			for _, dependent := range graph.dependents[idx] {
+				if len(graph.items[dependent].Parents) > 0 {
+					last = max(last, dependent)
+				}
+			}
+		}
+
+		if first > last {
+			first, last = len(graph.items), len(graph.items)-1
+			d.printVerbosef("The items are still in order, keeping the previous order")
+		} else {
+			d.printVerbosef("Ordering the items from index %s to %s", strconv.Itoa(first), strconv.Itoa(last))
+		}
 	}
-	d.printVerbosef("Shifting %s on index %s to index %s", children[currentIndex].Name, strconv.Itoa(childIndex), strconv.Itoa(dependencyIndex))
 
-	_, err = d.shiftTo(childIndex, dependencyIndex)
-	if err != nil {
-		return -1, err
+	// the items before the range keep their position, the dependencies of the
+	// items in the range are never after it
+	inDegree := make([]int, len(graph.items))
+	placed := make([]bool, len(graph.items))
+	placedAt := make([]int, len(graph.items))
+	for idx := range graph.items {
+		placedAt[idx] = idx
+		if idx < first || idx > last {
+			placed[idx] = true
+			continue
+		}
+
+		for _, dependency := range graph.dependencies[idx] {
+			if dependency >= first {
+				inDegree[idx] += 1
+			}
+		}
 	}
-	currentIndex += 1
 
-	return currentIndex, nil
-}
+	ready := &readyQueue{}
+	push := func(idx int) {
+		// shared items follow the parent that was placed last
+		parentPosition := -1
+		for _, parent := range graph.items[idx].Parents {
+			parentPosition = max(parentPosition, placedAt[graph.positions[parent]])
+		}
+		heap.Push(ready, readyItem{index: idx, parentPosition: parentPosition})
+	}
 
-func (d *DependencyTreeService[T]) updateTree() (map[string]*DependencyTreeItem[T], error) {
-	result := make(map[string]*DependencyTreeItem[T])
-	for i, item := range d.flatTree {
-		item.index = i
-		item.highest = -1
-		item.lowest = -1
+	for idx := first; idx <= last; idx++ {
+		if inDegree[idx] == 0 {
+			push(idx)
+		}
+	}
 
-		for _, dependency := range item.IsDependentOn() {
-			dependencyIndex, err := d.GetItemIndex(dependency)
-			if err != nil {
-				return nil, err
+	// removed items can shift the items before the range
+	result := make([]*DependencyTreeItem[T], 0, len(graph.items))
+	for _, item := range graph.items[:first] {
+		item.FlatIndex = len(result)
+		result = append(result, item)
+	}
+	for ready.Len() > 0 {
+		next, _ := heap.Pop(ready).(readyItem)
+		item := graph.items[next.index]
+		placed[next.index] = true
+		placedAt[next.index] = len(result)
+		item.FlatIndex = len(result)
+		result = append(result, item)
+		d.printVerbosef("Placing %s on index %s", item.Name, strconv.Itoa(item.FlatIndex))
+
+		for _, dependent := range graph.dependents[next.index] {
+			if placed[dependent] {
+				continue
 			}
 
-			if dependencyIndex > item.highest {
-				item.highest = dependencyIndex
-				if item.lowest == -1 {
-					item.lowest = dependencyIndex
-				}
-			}
-			if dependencyIndex < item.highest && dependencyIndex > item.lowest {
-				item.lowest = dependencyIndex
+			inDegree[dependent] -= 1
+			if inDegree[dependent] == 0 {
+				push(dependent)
 			}
 		}
+	}
 
-		if d.IsDebug() && d.IsVerbose() {
-			d.logger.Debug("%s [%s] %s -> HighestDependency: %s | LowestDependency: %s", fmt.Sprintf("%d", i), item.Name, fmt.Sprintf("%d", item.highest), fmt.Sprintf("%d", item.lowest))
-		}
+	if len(result) != last+1 {
+		return nil, graph.cycleError(placed)
+	}
 
-		result[item.Name] = item
+	for _, item := range graph.items[last+1:] {
+		item.FlatIndex = len(result)
+		result = append(result, item)
 	}
 
 	return result, nil
 }
 
-func (d *DependencyTreeService[T]) shiftChildItems(index int) (bool, error) {
-	currentChildIndex := 0
-	didShuffle := false
-	offsetParentIndex := index
-	for {
-		child := d.flatTree[index].Children[currentChildIndex]
-		shiftedItem := false
-
-		currentIdx, err := d.GetItemIndex(child.Name)
-		if err != nil {
-			return false, err
-		}
-
-		offset := offsetParentIndex + currentChildIndex + 1
+type readyItem struct {
+	index          int
+	parentPosition int
+}
 
-		if currentIdx != offset {
-			d.printVerbosef("Parent: %v, Child: %v", d.flatTree[index].Name, child.Name)
-			shiftedItem = true
+type readyQueue []readyItem
 
-			d.printVerbosef("Shifting %s child %v on index %s to index %s", d.flatTree[index].Name, child.Name, strconv.Itoa(currentIdx), strconv.Itoa(offset))
-			_, err := d.shiftTo(currentIdx, offset)
-			if err != nil {
-				return false, err
-			}
+func (q readyQueue) Len() int {
+	return len(q)
+}
 
-			if len(d.flatTree[offset].Children) > 0 {
-				shuffledChildren, err := d.shiftChildItems(offset)
-				if err != nil {
-					return false, err
-				}
+func (q readyQueue) Less(i, j int) bool {
+	if q[i].parentPosition != q[j].parentPosition {
+		return q[i].parentPosition > q[j].parentPosition
+	}
 
-				if shuffledChildren {
-					offsetParentIndex = offsetParentIndex + len(d.flatTree[offset].Children)
-					didShuffle = true
-				}
-			}
-		}
-		currentChildIndex += 1
+	return q[i].index < q[j].index
+}
 
-		if shiftedItem {
-			didShuffle = true
-			if child.CallBack != nil {
-				child.CallBack()
-			}
-		}
+func (q readyQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
 
-		if currentChildIndex >= len(d.flatTree[index].Children) {
-			break
-		}
+func (q *readyQueue) Push(x interface{}) {
+	if item, ok := x.(readyItem); ok {
+		*q = append(*q, item)
 	}
+}
 
-	return didShuffle, nil
+func (q *readyQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
 }
@@ -7,20 +7,32 @@ import (
 )
 
 type DependencyTreeItem[T interface{}] struct {
-	ID            string
-	Name          string
-	FlatIndex     int
-	index         int
-	highest       int
-	lowest        int
-	isDependentOn []string
-	parentName    string
-	Parent        *DependencyTreeItem[T]
-	obj           T
-	requiredBy    []string
-	Children      []*DependencyTreeItem[T]
-	CallBack      func()
-	Metadata      map[string]interface{}
+	ID                string
+	Name              string
+	FlatIndex         int
+	isDependentOn     []string
+	optional          []string
+	after             []string
+	before            []string
+	parentName        string
+	additionalParents []string
+	// parentDependencies are the dependencies on the parents added by Build,
+	// they are removed together with the parent.
+	parentDependencies []string
+	Parent             *DependencyTreeItem[T]
+	// Parents holds every parent of the item once the tree is built, the
+	// parent first followed by the additional ones.
+	Parents    []*DependencyTreeItem[T]
+	obj        T
+	requiredBy []string
+	Children   []*DependencyTreeItem[T]
+	// CallBack is called when a build moves the child item in the flat tree,
+	// by Build, BuildLayers, CriticalPath or an Executor.
+	CallBack func()
+	Metadata map[string]interface{}
+	// caseInsensitive follows the setting of the service holding the item,
+	// items on their own match ids and names exactly.
+	caseInsensitive bool
 }
 
 func NewDependencyTreeItem[T interface{}](id string, name string, value T) (*DependencyTreeItem[T], error) {
@@ -33,20 +45,22 @@ func NewDependencyTreeItem[T interface{}](id string, name string, value T) (*Dep
 	}
 
 	result := DependencyTreeItem[T]{
-		ID:            id,
-		Name:          name,
-		index:         0,
-		FlatIndex:     0,
-		highest:       0,
-		lowest:        0,
-		isDependentOn: []string{},
-		parentName:    "root",
-		Parent:        nil,
-		obj:           value,
-		requiredBy:    []string{},
-		Children:      []*DependencyTreeItem[T]{},
-		CallBack:      nil,
-		Metadata:      make(map[string]interface{}),
+		ID:                id,
+		Name:              name,
+		FlatIndex:         0,
+		isDependentOn:     []string{},
+		optional:          []string{},
+		after:             []string{},
+		before:            []string{},
+		parentName:        "root",
+		additionalParents: []string{},
+		Parent:            nil,
+		Parents:           []*DependencyTreeItem[T]{},
+		obj:               value,
+		requiredBy:        []string{},
+		Children:          []*DependencyTreeItem[T]{},
+		CallBack:          nil,
+		Metadata:          make(map[string]interface{}),
 	}
 
 	return &result, nil
@@ -61,11 +75,30 @@ func (dt *DependencyTreeItem[T]) SetParent(parent string) {
 	dt.parentName = parent
 }
 
+// AddParent adds the item to the group of another parent, so a shared item can
+// be a child of several items. Build places it after every one of its parents.
+func (dt *DependencyTreeItem[T]) AddParent(idOrName string) error {
+	if dt.equal(dt.parentName, idOrName) || dt.contains(dt.additionalParents, idOrName) {
+		return fmt.Errorf("parent %v already exists", idOrName)
+	}
+
+	dt.additionalParents = append(dt.additionalParents, idOrName)
+	return nil
+}
+
+func (dt *DependencyTreeItem[T]) AdditionalParents() []string {
+	return dt.additionalParents
+}
+
+// IsShared reports whether the item was placed under more than one parent by
+// the last Build.
+func (dt *DependencyTreeItem[T]) IsShared() bool {
+	return len(dt.Parents) > 1
+}
+
 func (dt *DependencyTreeItem[T]) DependsOn(idOrName string) error {
-	for _, item := range dt.isDependentOn {
-		if strings.EqualFold(item, idOrName) {
-			return fmt.Errorf("item %v already exists", idOrName)
-		}
+	if dt.hasDependency(idOrName) {
+		return fmt.Errorf("item %v already exists", idOrName)
 	}
 
 	dt.isDependentOn = append(dt.isDependentOn, idOrName)
@@ -76,6 +109,85 @@ func (dt *DependencyTreeItem[T]) IsDependentOn() []string {
 	return dt.isDependentOn
 }
 
+// DependsOnOptional makes the item come after another one only if it exists,
+// when it does not the dependency is ignored.
+func (dt *DependencyTreeItem[T]) DependsOnOptional(idOrName string) error {
+	if dt.hasDependency(idOrName) {
+		return fmt.Errorf("item %v already exists", idOrName)
+	}
+
+	dt.optional = append(dt.optional, idOrName)
+	return nil
+}
+
+func (dt *DependencyTreeItem[T]) OptionalDependencies() []string {
+	return dt.optional
+}
+
+// After makes the item come after another one when both are part of the tree.
+// It only changes the order, the item is not skipped if the other one fails.
+func (dt *DependencyTreeItem[T]) After(idOrName string) error {
+	if dt.contains(dt.after, idOrName) {
+		return fmt.Errorf("item %v already exists", idOrName)
+	}
+
+	dt.after = append(dt.after, idOrName)
+	return nil
+}
+
+func (dt *DependencyTreeItem[T]) OrderedAfter() []string {
+	return dt.after
+}
+
+// Before makes the item come before another one when both are part of the
+// tree. It only changes the order, the other item is not skipped if this one
+// fails.
+func (dt *DependencyTreeItem[T]) Before(idOrName string) error {
+	if dt.contains(dt.before, idOrName) {
+		return fmt.Errorf("item %v already exists", idOrName)
+	}
+
+	dt.before = append(dt.before, idOrName)
+	return nil
+}
+
+func (dt *DependencyTreeItem[T]) OrderedBefore() []string {
+	return dt.before
+}
+
+// allDependencies returns the dependencies followed by the optional ones.
+func (dt *DependencyTreeItem[T]) allDependencies() []string {
+	result := make([]string, 0, len(dt.isDependentOn)+len(dt.optional))
+	result = append(result, dt.isDependentOn...)
+	return append(result, dt.optional...)
+}
+
+func (dt *DependencyTreeItem[T]) hasDependency(idOrName string) bool {
+	return dt.contains(dt.isDependentOn, idOrName) || dt.contains(dt.optional, idOrName)
+}
+
+func (dt *DependencyTreeItem[T]) contains(values []string, value string) bool {
+	for _, item := range values {
+		if dt.equal(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (dt *DependencyTreeItem[T]) equal(a, b string) bool {
+	if dt.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+func (dt *DependencyTreeItem[T]) Value() T {
+	return dt.obj
+}
+
 func (dt *DependencyTreeItem[T]) GetParentName() string {
 	if dt.Parent != nil {
 		return dt.Parent.Name
@@ -97,10 +209,8 @@ func (dt *DependencyTreeItem[T]) RequiredBy() []string {
 }
 
 func (dt *DependencyTreeItem[T]) AddRequiredBy(id string) {
-	for _, item := range dt.requiredBy {
-		if strings.EqualFold(item, id) {
-			return
-		}
+	if dt.contains(dt.requiredBy, id) {
+		return
 	}
 
 	dt.requiredBy = append(dt.requiredBy, id)
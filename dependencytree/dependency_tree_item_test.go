@@ -25,6 +25,62 @@ func TestDependsOn(t *testing.T) {
 	})
 }
 
+func TestDependsOnOptional(t *testing.T) {
+	dt := &DependencyTreeItem[MockObject1]{
+		isDependentOn: []string{"item1"},
+	}
+
+	t.Run("Optional dependency on a dependency", func(t *testing.T) {
+		err := dt.DependsOnOptional("item1")
+		assert.Equal(t, fmt.Errorf("item item1 already exists"), err)
+	})
+
+	t.Run("Optional dependency on new item", func(t *testing.T) {
+		err := dt.DependsOnOptional("item2")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"item2"}, dt.OptionalDependencies())
+		assert.Equal(t, []string{"item1"}, dt.IsDependentOn())
+	})
+
+	t.Run("Dependency on an optional dependency", func(t *testing.T) {
+		err := dt.DependsOn("item2")
+		assert.Equal(t, fmt.Errorf("item item2 already exists"), err)
+	})
+}
+
+func TestOrderingHints(t *testing.T) {
+	dt := &DependencyTreeItem[MockObject1]{}
+
+	t.Run("After", func(t *testing.T) {
+		assert.NoError(t, dt.After("item1"))
+		assert.Equal(t, fmt.Errorf("item item1 already exists"), dt.After("item1"))
+		assert.Equal(t, []string{"item1"}, dt.OrderedAfter())
+	})
+
+	t.Run("Before", func(t *testing.T) {
+		assert.NoError(t, dt.Before("item2"))
+		assert.Equal(t, fmt.Errorf("item item2 already exists"), dt.Before("item2"))
+		assert.Equal(t, []string{"item2"}, dt.OrderedBefore())
+		assert.Empty(t, dt.IsDependentOn())
+	})
+}
+
+func TestItemAddParent(t *testing.T) {
+	dt, _ := NewDependencyTreeItem("item", "item", MockObject1{})
+	dt.SetParent("parent1")
+
+	t.Run("Add new parent", func(t *testing.T) {
+		assert.NoError(t, dt.AddParent("parent2"))
+		assert.Equal(t, []string{"parent2"}, dt.AdditionalParents())
+		assert.False(t, dt.IsShared())
+	})
+
+	t.Run("Add existing parent", func(t *testing.T) {
+		assert.Equal(t, fmt.Errorf("parent parent1 already exists"), dt.AddParent("parent1"))
+		assert.Equal(t, fmt.Errorf("parent parent2 already exists"), dt.AddParent("parent2"))
+	})
+}
+
 func TestGetProperty(t *testing.T) {
 	dt := &DependencyTreeItem[MockObject1]{
 		Metadata: map[string]interface{}{
@@ -95,3 +151,14 @@ func TestRequiredBy(t *testing.T) {
 		assert.Equal(t, []string{"item1", "item2"}, result)
 	})
 }
+
+func TestValue(t *testing.T) {
+	dt, err := NewDependencyTreeItem("item1", "item 1", MockObject1{id: "item1", someStoredValue: "value"})
+	assert.NoError(t, err)
+
+	t.Run("Get value", func(t *testing.T) {
+		value := dt.Value()
+		assert.Equal(t, "item1", value.ID())
+		assert.Equal(t, "value", value.StoredValue())
+	})
+}
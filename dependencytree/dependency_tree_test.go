@@ -1,6 +1,7 @@
 package dependencytree
 
 import (
+	"fmt"
 	"testing"
 
 	log "github.com/cjlapao/common-go-logger"
@@ -90,3 +91,461 @@ func TestBuild(t *testing.T) {
 		cleanTestBuild(dpService)
 	})
 }
+
+func TestBuildOrder(t *testing.T) {
+	t.Run("Children are kept right after their parent", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("item_2", "item 2", MockObject1{id: "item_2"})
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		_, _ = service.AddRootItem("item_3", "item 3", MockObject1{id: "item_3"})
+		_, _ = service.AddItem("item_2_child_2", "item 2 Child 2", "item_2", MockObject1{id: "item_2_child_2"})
+		_, _ = service.AddItem("item_2_child_1", "item 2 Child 1", "item_2", MockObject1{id: "item_2_child_1"})
+		_, _ = service.AddItem("item_2_child_1_child_1", "item 2 Child 1 Child 1", "item_2_child_1", MockObject1{id: "item_2_child_1_child_1"})
+		_ = service.DependsOn("item_2", "item_1")
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		ids := []string{}
+		for _, item := range values {
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, []string{"item_1", "item_2", "item_2_child_2", "item_2_child_1", "item_2_child_1_child_1", "item_3"}, ids)
+		for idx, item := range values {
+			assert.Equal(t, idx, item.FlatIndex)
+		}
+	})
+
+	t.Run("Items without dependencies keep their order", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_, _ = service.AddRootItem("d", "d", MockObject1{id: "d"})
+		_ = service.DependsOn("a", "c")
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		require.Len(t, values, 4)
+		assert.Equal(t, "b", values[0].ID)
+		assert.Equal(t, "c", values[1].ID)
+		assert.Equal(t, "a", values[2].ID)
+		assert.Equal(t, "d", values[3].ID)
+	})
+
+	t.Run("Building twice gives the same order", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("item_2", "item 2", MockObject1{id: "item_2"})
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		_, _ = service.AddItem("item_2_child_1", "item 2 Child 1", "item_2", MockObject1{id: "item_2_child_1"})
+		_ = service.DependsOn("item_2", "item_1")
+
+		first, err := service.Build()
+		require.NoError(t, err)
+		firstIds := []string{}
+		for _, item := range first {
+			firstIds = append(firstIds, item.ID)
+		}
+
+		second, err := service.Build()
+		require.NoError(t, err)
+		secondIds := []string{}
+		for _, item := range second {
+			secondIds = append(secondIds, item.ID)
+		}
+
+		assert.Equal(t, firstIds, secondIds)
+		assert.Len(t, service.GetItem("item_2").Children, 1)
+	})
+
+	t.Run("Only the items out of order are sorted again", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("api", "database")
+
+		values, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "api", "metrics"}, itemIDs(values))
+
+		// new children are moved right after their parent
+		_, _ = service.AddItem("migrations", "Migrations", "database", MockObject1{id: "migrations"})
+		values, err = service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "migrations", "api", "metrics"}, itemIDs(values))
+
+		_, _ = service.AddRootItem("auth", "Auth", MockObject1{id: "auth"})
+		_ = service.DependsOn("api", "auth")
+		values, err = service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "migrations", "metrics", "auth", "api"}, itemIDs(values))
+
+		require.NoError(t, service.RemoveDependencyTreeItem(service.GetItem("metrics")))
+		values, err = service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "migrations", "auth", "api"}, itemIDs(values))
+		for idx, item := range values {
+			assert.Equal(t, idx, item.FlatIndex)
+		}
+
+		_ = service.DependsOn("config", "api")
+		_, err = service.Build()
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, "circular dependency detected: config (Config) -> api (API) -> database (Database) -> config (Config)", err.Error())
+		assert.Equal(t, []string{"config", "database", "migrations", "auth", "api"}, itemIDs(service.FlatTree()))
+	})
+
+	t.Run("Incremental builds keep children right after their parent", func(t *testing.T) {
+		incremental := New[MockObject1]()
+		_, _ = incremental.AddRootItem("p", "p", MockObject1{id: "p"})
+		_, _ = incremental.AddRootItem("x", "x", MockObject1{id: "x"})
+		_, _ = incremental.AddItem("c1", "c1", "p", MockObject1{id: "c1"})
+		_, err := incremental.Build()
+		require.NoError(t, err)
+		_, _ = incremental.AddItem("c2", "c2", "p", MockObject1{id: "c2"})
+
+		values, err := incremental.Build()
+
+		require.NoError(t, err)
+		fresh := New[MockObject1]()
+		_, _ = fresh.AddRootItem("p", "p", MockObject1{id: "p"})
+		_, _ = fresh.AddRootItem("x", "x", MockObject1{id: "x"})
+		_, _ = fresh.AddItem("c1", "c1", "p", MockObject1{id: "c1"})
+		_, _ = fresh.AddItem("c2", "c2", "p", MockObject1{id: "c2"})
+		freshValues, err := fresh.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p", "c1", "c2", "x"}, itemIDs(values))
+		assert.Equal(t, itemIDs(freshValues), itemIDs(values))
+	})
+
+	t.Run("Self dependencies are found after a build", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, err := service.Build()
+		require.NoError(t, err)
+		_ = service.DependsOn("a", "a")
+
+		_, err = service.Build()
+
+		var cycleErr *CycleError
+		assert.ErrorAs(t, err, &cycleErr)
+	})
+
+	t.Run("Clear sorts every item again", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.Build()
+
+		service.Clear()
+		_, _ = service.AddRootItem("parent", "parent", MockObject1{id: "parent"})
+		_, _ = service.AddRootItem("other", "other", MockObject1{id: "other"})
+		_, _ = service.AddItem("child", "child", "parent", MockObject1{id: "child"})
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"parent", "child", "other"}, itemIDs(values))
+	})
+
+	t.Run("Large reversed chain", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		size := 2000
+		for i := size - 1; i >= 0; i-- {
+			id := fmt.Sprintf("item_%d", i)
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		for i := 1; i < size; i++ {
+			_ = service.DependsOn(fmt.Sprintf("item_%d", i), fmt.Sprintf("item_%d", i-1))
+		}
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		require.Len(t, values, size)
+		for i, item := range values {
+			assert.Equal(t, fmt.Sprintf("item_%d", i), item.ID)
+		}
+	})
+
+	t.Run("Missing dependency", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		item, _ := service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_ = item.DependsOn("missing")
+
+		_, err := service.Build()
+
+		assert.EqualError(t, err, "dependency on missing of service a was not found in the context configuration")
+	})
+
+	t.Run("Optional dependencies", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_ = service.DependsOnOptional("a", "b")
+		_ = service.DependsOnOptional("a", "missing")
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		require.Len(t, values, 2)
+		assert.Equal(t, "b", values[0].ID)
+		assert.Equal(t, "a", values[1].ID)
+		// optional dependencies only change the order
+		assert.Empty(t, values[0].RequiredBy())
+		assert.EqualError(t, service.DependsOnOptional("missing", "a"), "item missing not found")
+	})
+
+	t.Run("Ordering hints", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("metrics", "metrics", MockObject1{id: "metrics"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("logging", "logging", MockObject1{id: "logging"})
+		_ = service.After("metrics", "api")
+		_ = service.Before("logging", "api")
+		_ = service.After("api", "missing")
+		_ = service.Before("api", "missing")
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		ids := []string{}
+		for _, item := range values {
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, []string{"logging", "api", "metrics"}, ids)
+		assert.Empty(t, values[1].RequiredBy())
+		assert.EqualError(t, service.After("missing", "api"), "item missing not found")
+		assert.EqualError(t, service.Before("missing", "api"), "item missing not found")
+	})
+
+	t.Run("Ordering hints can create cycles", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "Item A", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "Item B", MockObject1{id: "b"})
+		_ = service.DependsOn("a", "b")
+		_ = service.Before("a", "b")
+
+		_, err := service.Build()
+
+		assert.EqualError(t, err, "circular dependency detected: a (Item A) -> b (Item B) -> a (Item A)")
+	})
+
+	t.Run("Circular dependency", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "Item A", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "Item B", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "Item C", MockObject1{id: "c"})
+		_, _ = service.AddRootItem("d", "Item D", MockObject1{id: "d"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "c")
+		_ = service.DependsOn("c", "a")
+		_ = service.DependsOn("d", "a")
+
+		_, err := service.Build()
+
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		require.Len(t, cycleErr.Cycles, 1)
+		assert.Equal(t, []CycleItem{{"a", "Item A"}, {"b", "Item B"}, {"c", "Item C"}, {"a", "Item A"}}, cycleErr.Cycles[0])
+		assert.EqualError(t, err, "circular dependency detected: a (Item A) -> b (Item B) -> c (Item C) -> a (Item A)")
+	})
+
+	t.Run("Every cycle is reported", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_, _ = service.AddItem("c_child", "c child", "c", MockObject1{id: "c_child"})
+		_, _ = service.AddRootItem("d", "d", MockObject1{id: "d"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "a")
+		_ = service.DependsOn("c", "c_child")
+		_ = service.DependsOn("d", "d")
+
+		_, err := service.Build()
+
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		require.Len(t, cycleErr.Cycles, 3)
+		assert.Equal(t, []CycleItem{{"a", "a"}, {"b", "b"}, {"a", "a"}}, cycleErr.Cycles[0])
+		assert.Equal(t, []CycleItem{{"c", "c"}, {"c_child", "c child"}, {"c", "c"}}, cycleErr.Cycles[1])
+		assert.Equal(t, []CycleItem{{"d", "d"}, {"d", "d"}}, cycleErr.Cycles[2])
+	})
+
+	t.Run("Callbacks only run for the children that moved", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		handlers, _ := service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		users, _ := service.AddItem("users", "Users", "database", MockObject1{id: "users"})
+		calls := map[string]int{}
+		handlers.CallBack = func() { calls["handlers"] += 1 }
+		users.CallBack = func() { calls["users"] += 1 }
+
+		_, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"handlers": 1}, calls)
+
+		_, err = service.Build()
+		require.NoError(t, err)
+		_, err = service.BuildLayers()
+		require.NoError(t, err)
+		_, err = service.CriticalPath(nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"handlers": 1}, calls)
+	})
+}
+
+func TestBuildLayers(t *testing.T) {
+	layerIds := func(layers [][]*DependencyTreeItem[MockObject1]) [][]string {
+		result := [][]string{}
+		for _, layer := range layers {
+			ids := []string{}
+			for _, item := range layer {
+				ids = append(ids, item.ID)
+			}
+			result = append(result, ids)
+		}
+		return result
+	}
+
+	t.Run("Independent items share a layer", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("worker", "worker", MockObject1{id: "worker"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("worker", "database")
+		_ = service.DependsOn("gateway", "api")
+
+		layers, err := service.BuildLayers()
+
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"database", "cache"},
+			{"api", "worker"},
+			{"gateway"},
+		}, layerIds(layers))
+	})
+
+	t.Run("Children are placed after their parent", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		_, _ = service.AddRootItem("item_2", "item 2", MockObject1{id: "item_2"})
+		_, _ = service.AddItem("item_1_child_1", "item 1 Child 1", "item_1", MockObject1{id: "item_1_child_1"})
+		_, _ = service.AddItem("item_1_child_2", "item 1 Child 2", "item_1", MockObject1{id: "item_1_child_2"})
+		_ = service.DependsOn("item_2", "item_1_child_2")
+
+		layers, err := service.BuildLayers()
+
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"item_1"},
+			{"item_1_child_1", "item_1_child_2"},
+			{"item_2"},
+		}, layerIds(layers))
+	})
+
+	t.Run("Empty tree", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+
+		layers, err := service.BuildLayers()
+
+		require.NoError(t, err)
+		assert.Empty(t, layers)
+	})
+
+	t.Run("Circular dependency", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "a")
+
+		layers, err := service.BuildLayers()
+
+		var cycleErr *CycleError
+		assert.ErrorAs(t, err, &cycleErr)
+		assert.Nil(t, layers)
+	})
+}
+
+func TestBuildTeardown(t *testing.T) {
+	t.Run("Reverse of the build order", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("item_2", "item 2", MockObject1{id: "item_2"})
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		_, _ = service.AddItem("item_1_child_1", "item 1 Child 1", "item_1", MockObject1{id: "item_1_child_1"})
+		_ = service.DependsOn("item_2", "item_1")
+
+		values, err := service.BuildTeardown()
+
+		require.NoError(t, err)
+		ids := []string{}
+		for _, item := range values {
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, []string{"item_2", "item_1_child_1", "item_1"}, ids)
+	})
+
+	t.Run("Circular dependency", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_ = service.DependsOn("a", "a")
+
+		values, err := service.BuildTeardown()
+
+		assert.Error(t, err)
+		assert.Nil(t, values)
+	})
+}
+
+func newBenchmarkService(size int) *DependencyTreeService[MockObject1] {
+	service := New[MockObject1]()
+	for i := size - 1; i >= 0; i-- {
+		id := fmt.Sprintf("item_%d", i)
+		parent := "root"
+		if i%50 != 0 {
+			parent = fmt.Sprintf("item_%d", i-i%50)
+		}
+		_, _ = service.AddItem(id, id, parent, MockObject1{id: id})
+	}
+	for i := 50; i < size; i += 50 {
+		_ = service.DependsOn(fmt.Sprintf("item_%d", i), fmt.Sprintf("item_%d", i-50))
+		_ = service.DependsOn(fmt.Sprintf("item_%d", i), fmt.Sprintf("item_%d", i/2))
+	}
+
+	return service
+}
+
+func benchmarkBuild(b *testing.B, size int) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		service := newBenchmarkService(size)
+		b.StartTimer()
+
+		if _, err := service.Build(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuild1000(b *testing.B) {
+	benchmarkBuild(b, 1000)
+}
+
+func BenchmarkBuild10000(b *testing.B) {
+	benchmarkBuild(b, 10000)
+}
+
+func BenchmarkBuild50000(b *testing.B) {
+	benchmarkBuild(b, 50000)
+}
@@ -0,0 +1,286 @@
+package dependencytree
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MermaidStyleProperty is the metadata key holding the Mermaid style of an
+// item, it accepts a string like "fill:#f9f,stroke:#333", a map[string]string
+// or a map[string]interface{}.
+const MermaidStyleProperty = "mermaid"
+
+// PlantUMLStyleProperty is the metadata key holding the PlantUML style of an
+// item, it accepts a string like "#LightBlue" or "#LightBlue;line:Blue".
+const PlantUMLStyleProperty = "plantuml"
+
+type MermaidOptions struct {
+	// Label decides if the nodes are labelled by name or by id, defaults to name.
+	Label NodeLabel
+	// Direction is the direction of the flowchart, defaults to "TD".
+	Direction string
+}
+
+type PlantUMLOptions struct {
+	// Title is written as the title of the diagram when set.
+	Title string
+	// Label decides if the components are labelled by name or by id, defaults to name.
+	Label NodeLabel
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Dependencies are drawn
+// as arrows from the item to its dependency, dotted for optional ones, ordering
+// hints end in a circle on the item started first. Edges are labelled with
+// their reason when they have one. Children are grouped in
+// a subgraph under their parent and linked to it with a dotted line, shared
+// items are only grouped under their first parent and linked to the others.
+func (d *DependencyTreeService[T]) WriteMermaid(w io.Writer, opts MermaidOptions) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	direction := opts.Direction
+	if direction == "" {
+		direction = "TD"
+	}
+
+	ids := d.diagramIDs()
+	roots, children := d.hierarchy()
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("flowchart %s\n", direction))
+
+	var writeNode func(item *DependencyTreeItem[T], level int)
+	writeNode = func(item *DependencyTreeItem[T], level int) {
+		indent := strings.Repeat("  ", level)
+		node := fmt.Sprintf("%s[\"%s\"]\n", ids[item], mermaidEscape(nodeLabel(item, opts.Label)))
+		if len(children[item]) == 0 {
+			sb.WriteString(indent + node)
+			return
+		}
+
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s_group[\"%s\"]\n", indent, ids[item], mermaidEscape(nodeLabel(item, opts.Label))))
+		sb.WriteString(indent + "  " + node)
+		for _, child := range children[item] {
+			writeNode(child, level+1)
+		}
+		sb.WriteString(indent + "end\n")
+	}
+
+	for _, item := range roots {
+		writeNode(item, 1)
+	}
+
+	d.forEachEdge(func(item *DependencyTreeItem[T], other *DependencyTreeItem[T], edge Edge) {
+		arrow := "-->"
+		switch edge.Kind {
+		case OptionalEdge:
+			arrow = "-.->"
+		case AfterEdge, BeforeEdge:
+			arrow = "--o"
+		}
+		if reason := edge.Reason(); reason != "" {
+			arrow += fmt.Sprintf("|\"%s\"|", mermaidEscape(reason))
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", ids[item], arrow, ids[other]))
+	})
+
+	for _, item := range d.flatTree {
+		for _, child := range children[item] {
+			sb.WriteString(fmt.Sprintf("  %s -.- %s\n", ids[item], ids[child]))
+		}
+	}
+
+	for _, item := range d.flatTree {
+		for _, parent := range d.additionalParents(item) {
+			sb.WriteString(fmt.Sprintf("  %s -.- %s\n", ids[parent], ids[item]))
+		}
+	}
+
+	for _, item := range d.flatTree {
+		if style := mermaidStyle(item); style != "" {
+			sb.WriteString(fmt.Sprintf("  style %s %s\n", ids[item], style))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WritePlantUML writes the graph as a PlantUML component diagram. Dependencies
+// are drawn as arrows from the item to its dependency, dotted for optional
+// ones and gray for ordering hints, and labelled with the reason of the edge. Children are nested inside
+// their parent, shared items only inside their first parent and linked to the
+// others with a dotted line.
+func (d *DependencyTreeService[T]) WritePlantUML(w io.Writer, opts PlantUMLOptions) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ids := d.diagramIDs()
+	roots, children := d.hierarchy()
+
+	sb := strings.Builder{}
+	sb.WriteString("@startuml\n")
+	if opts.Title != "" {
+		sb.WriteString(fmt.Sprintf("title %s\n", plantUMLTitle(opts.Title)))
+	}
+
+	var writeComponent func(item *DependencyTreeItem[T], level int)
+	writeComponent = func(item *DependencyTreeItem[T], level int) {
+		indent := strings.Repeat("  ", level)
+		component := fmt.Sprintf("%scomponent \"%s\" as %s", indent, plantUMLEscape(nodeLabel(item, opts.Label)), ids[item])
+		if style, ok := item.GetProperty(PlantUMLStyleProperty, "").(string); ok && style != "" {
+			component += " " + style
+		}
+
+		if len(children[item]) == 0 {
+			sb.WriteString(component + "\n")
+			return
+		}
+
+		sb.WriteString(component + " {\n")
+		for _, child := range children[item] {
+			writeComponent(child, level+1)
+		}
+		sb.WriteString(indent + "}\n")
+	}
+
+	for _, item := range roots {
+		writeComponent(item, 0)
+	}
+
+	d.forEachEdge(func(item *DependencyTreeItem[T], other *DependencyTreeItem[T], edge Edge) {
+		arrow := "-->"
+		switch edge.Kind {
+		case OptionalEdge:
+			arrow = "..>"
+		case AfterEdge, BeforeEdge:
+			arrow = "-[#gray]->"
+		}
+		label := ""
+		if reason := edge.Reason(); reason != "" {
+			label = " : " + reason
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s%s\n", ids[item], arrow, ids[other], label))
+	})
+
+	for _, item := range d.flatTree {
+		for _, parent := range d.additionalParents(item) {
+			sb.WriteString(fmt.Sprintf("%s .. %s\n", ids[parent], ids[item]))
+		}
+	}
+
+	sb.WriteString("@enduml\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// forEachEdge calls fn for every dependency and ordering hint that can be
+// resolved, leaving out the dependencies on the parents that Build adds. The
+// item is started after the other one, so the Before hints are passed with
+// their items swapped.
+func (d *DependencyTreeService[T]) forEachEdge(fn func(item *DependencyTreeItem[T], other *DependencyTreeItem[T], edge Edge)) {
+	for _, item := range d.flatTree {
+		parents := d.itemParents(item)
+		for idx, dependency := range item.allDependencies() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil || slices.Contains(parents, dependencyItem) {
+				continue
+			}
+
+			kind := ExplicitEdge
+			if idx >= len(item.IsDependentOn()) {
+				kind = OptionalEdge
+			}
+			fn(item, dependencyItem, d.edge(item, dependency, kind))
+		}
+
+		for _, other := range item.OrderedAfter() {
+			if otherItem := d.getItem(other); otherItem != nil {
+				fn(item, otherItem, d.edge(item, other, AfterEdge))
+			}
+		}
+		for _, other := range item.OrderedBefore() {
+			if otherItem := d.getItem(other); otherItem != nil {
+				fn(otherItem, item, d.edge(item, other, BeforeEdge))
+			}
+		}
+	}
+}
+
+// diagramIDs returns an identifier for every item that is safe to use in
+// Mermaid and PlantUML, ids with other characters are replaced with
+// underscores and made unique with a suffix. The n_ prefix keeps ids like end
+// from being read as keywords, and the _group name of the Mermaid subgraph of
+// every id is reserved as well.
+func (d *DependencyTreeService[T]) diagramIDs() map[*DependencyTreeItem[T]]string {
+	result := make(map[*DependencyTreeItem[T]]string, len(d.flatTree))
+	used := make(map[string]bool, len(d.flatTree))
+	for _, item := range d.flatTree {
+		id := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+				return r
+			}
+			return '_'
+		}, item.ID)
+		id = "n_" + id
+
+		unique := id
+		for suffix := 2; used[unique] || used[unique+"_group"]; suffix++ {
+			unique = fmt.Sprintf("%s_%d", id, suffix)
+		}
+
+		used[unique] = true
+		used[unique+"_group"] = true
+		result[item] = unique
+	}
+
+	return result
+}
+
+// mermaidStyle returns the style of the item, the properties of a map are
+// sorted by name so the output is stable.
+func mermaidStyle[T interface{}](item *DependencyTreeItem[T]) string {
+	properties := map[string]string{}
+	switch value := item.GetProperty(MermaidStyleProperty, nil).(type) {
+	case string:
+		return value
+	case map[string]string:
+		for key, property := range value {
+			properties[key] = property
+		}
+	case map[string]interface{}:
+		for key, property := range value {
+			properties[key] = fmt.Sprintf("%v", property)
+		}
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	styles := make([]string, 0, len(keys))
+	for _, key := range keys {
+		styles = append(styles, fmt.Sprintf("%s:%s", key, properties[key]))
+	}
+	return strings.Join(styles, ",")
+}
+
+func mermaidEscape(value string) string {
+	return strings.ReplaceAll(value, `"`, "#quot;")
+}
+
+// plantUMLTitle keeps the title on one line, PlantUML breaks it at every \n.
+func plantUMLTitle(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+func plantUMLEscape(value string) string {
+	return strings.ReplaceAll(value, `"`, "'")
+}
@@ -0,0 +1,195 @@
+package dependencytree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	t.Run("Writes dependencies, subgraphs and styles", func(t *testing.T) {
+		service := New[MockObject1]()
+		database, _ := service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("api-handlers", "Handlers", "api", MockObject1{id: "api-handlers"})
+		_, _ = service.AddItem("api.handlers", "Other \"Handlers\"", "api", MockObject1{id: "api.handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api-handlers", "database")
+		_ = database.SetProperty(MermaidStyleProperty, map[string]string{"stroke": "#333", "fill": "#f9f"})
+		_ = api.SetProperty(MermaidStyleProperty, "fill:#bbf")
+		_ = api.SetProperty(PlantUMLStyleProperty, "#LightBlue")
+		_, err := service.Build()
+		require.NoError(t, err)
+		buffer := bytes.Buffer{}
+
+		err = service.WriteMermaid(&buffer, MermaidOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, `flowchart TD
+  n_database["Database"]
+  subgraph n_api_group["API"]
+    n_api["API"]
+    n_api_handlers["Handlers"]
+    n_api_handlers_2["Other #quot;Handlers#quot;"]
+  end
+  n_api --> n_database
+  n_api_handlers --> n_database
+  n_api -.- n_api_handlers
+  n_api -.- n_api_handlers_2
+  style n_database fill:#f9f,stroke:#333
+  style n_api fill:#bbf
+`, buffer.String())
+	})
+
+	t.Run("Optional dependencies are dotted arrows", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.DependsOnOptional("api", "cache")
+		_ = service.DependsOnOptional("api", "missing")
+		mermaid := bytes.Buffer{}
+		plantUML := bytes.Buffer{}
+
+		require.NoError(t, service.WriteMermaid(&mermaid, MermaidOptions{}))
+		require.NoError(t, service.WritePlantUML(&plantUML, PlantUMLOptions{}))
+
+		assert.Equal(t, "flowchart TD\n  n_cache[\"Cache\"]\n  n_api[\"API\"]\n  n_api -.-> n_cache\n", mermaid.String())
+		assert.Contains(t, plantUML.String(), "n_api ..> n_cache\n")
+	})
+
+	t.Run("Ordering hints have their own arrows", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		require.NoError(t, service.AddEdge(Edge{From: "metrics", To: "api", Kind: AfterEdge, Metadata: map[string]interface{}{EdgeReasonProperty: "scrapes the api"}}))
+		_ = service.Before("database", "api")
+		mermaid := bytes.Buffer{}
+		plantUML := bytes.Buffer{}
+
+		require.NoError(t, service.WriteMermaid(&mermaid, MermaidOptions{}))
+		require.NoError(t, service.WritePlantUML(&plantUML, PlantUMLOptions{}))
+
+		assert.Contains(t, mermaid.String(), "  n_api --o n_database\n")
+		assert.Contains(t, mermaid.String(), "  n_metrics --o|\"scrapes the api\"| n_api\n")
+		assert.Contains(t, plantUML.String(), "n_api -[#gray]-> n_database\n")
+		assert.Contains(t, plantUML.String(), "n_metrics -[#gray]-> n_api : scrapes the api\n")
+	})
+
+	t.Run("Styles can be loaded maps", func(t *testing.T) {
+		service := New[MockObject1]()
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = api.SetProperty(MermaidStyleProperty, map[string]interface{}{"stroke-width": 2, "fill": "#bbf"})
+		buffer := bytes.Buffer{}
+
+		require.NoError(t, service.WriteMermaid(&buffer, MermaidOptions{}))
+
+		assert.Contains(t, buffer.String(), "  style n_api fill:#bbf,stroke-width:2\n")
+	})
+
+	t.Run("Ids do not clash with keywords or subgraphs", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("end", "End", MockObject1{id: "end"})
+		_, _ = service.AddItem("step", "Step", "end", MockObject1{id: "step"})
+		_, _ = service.AddRootItem("end_group", "End group", MockObject1{id: "end_group"})
+		_ = service.DependsOn("end_group", "end")
+		buffer := bytes.Buffer{}
+
+		require.NoError(t, service.WriteMermaid(&buffer, MermaidOptions{}))
+
+		assert.Equal(t, `flowchart TD
+  subgraph n_end_group["End"]
+    n_end["End"]
+    n_step["Step"]
+  end
+  n_end_group_2["End group"]
+  n_end_group_2 --> n_end
+  n_end -.- n_step
+`, buffer.String())
+	})
+
+	t.Run("Labels by id with a direction", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		buffer := bytes.Buffer{}
+
+		err := service.WriteMermaid(&buffer, MermaidOptions{Label: LabelByID, Direction: "LR"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "flowchart LR\n  n_item_1[\"item_1\"]\n", buffer.String())
+	})
+
+	t.Run("Returns the writer error", func(t *testing.T) {
+		service := New[MockObject1]()
+		database, _ := service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("api-handlers", "Handlers", "api", MockObject1{id: "api-handlers"})
+		_, _ = service.AddItem("api.handlers", "Other \"Handlers\"", "api", MockObject1{id: "api.handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api-handlers", "database")
+		_ = database.SetProperty(MermaidStyleProperty, map[string]string{"stroke": "#333", "fill": "#f9f"})
+		_ = api.SetProperty(MermaidStyleProperty, "fill:#bbf")
+		_ = api.SetProperty(PlantUMLStyleProperty, "#LightBlue")
+		err := service.WriteMermaid(failingWriter{}, MermaidOptions{})
+
+		assert.EqualError(t, err, "write failed")
+	})
+}
+
+func TestWritePlantUML(t *testing.T) {
+	t.Run("Writes dependencies, nested components and styles", func(t *testing.T) {
+		service := New[MockObject1]()
+		database, _ := service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("api-handlers", "Handlers", "api", MockObject1{id: "api-handlers"})
+		_, _ = service.AddItem("api.handlers", "Other \"Handlers\"", "api", MockObject1{id: "api.handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api-handlers", "database")
+		_ = database.SetProperty(MermaidStyleProperty, map[string]string{"stroke": "#333", "fill": "#f9f"})
+		_ = api.SetProperty(MermaidStyleProperty, "fill:#bbf")
+		_ = api.SetProperty(PlantUMLStyleProperty, "#LightBlue")
+		buffer := bytes.Buffer{}
+
+		err := service.WritePlantUML(&buffer, PlantUMLOptions{Title: "Services"})
+
+		require.NoError(t, err)
+		assert.Equal(t, `@startuml
+title Services
+component "Database" as n_database
+component "API" as n_api #LightBlue {
+  component "Handlers" as n_api_handlers
+  component "Other 'Handlers'" as n_api_handlers_2
+}
+n_api --> n_database
+n_api_handlers --> n_database
+@enduml
+`, buffer.String())
+	})
+
+	t.Run("Keeps the title on one line", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+
+		err := New[MockObject1]().WritePlantUML(&buffer, PlantUMLOptions{Title: "Services\nstartup order"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "@startuml\ntitle Services\\nstartup order\n@enduml\n", buffer.String())
+	})
+
+	t.Run("Returns the writer error", func(t *testing.T) {
+		service := New[MockObject1]()
+		database, _ := service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("api-handlers", "Handlers", "api", MockObject1{id: "api-handlers"})
+		_, _ = service.AddItem("api.handlers", "Other \"Handlers\"", "api", MockObject1{id: "api.handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api-handlers", "database")
+		_ = database.SetProperty(MermaidStyleProperty, map[string]string{"stroke": "#333", "fill": "#f9f"})
+		_ = api.SetProperty(MermaidStyleProperty, "fill:#bbf")
+		_ = api.SetProperty(PlantUMLStyleProperty, "#LightBlue")
+		err := service.WritePlantUML(failingWriter{}, PlantUMLOptions{})
+
+		assert.EqualError(t, err, "write failed")
+	})
+}
@@ -0,0 +1,181 @@
+package dependencytree
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DOTAttributesProperty is the metadata key holding extra Graphviz attributes
+// for the node of an item, it accepts a map[string]string or a
+// map[string]interface{}, for example {"color": "red", "shape": "cylinder"}.
+const DOTAttributesProperty = "dot"
+
+// NodeLabel decides which field of an item is used to label it in a diagram.
+type NodeLabel string
+
+const (
+	LabelByName NodeLabel = "name"
+	LabelByID   NodeLabel = "id"
+)
+
+type DOTOptions struct {
+	// GraphName is the name of the digraph, defaults to "dependencies".
+	GraphName string
+	// Label decides if the nodes are labelled by name or by id, defaults to name.
+	Label NodeLabel
+	// RankDir sets the Graphviz rankdir attribute, for example "LR".
+	RankDir string
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Dependencies are drawn
+// as solid edges from the item to its dependency, dotted for optional ones,
+// ordering hints are gray edges to the item started first. Edges are labelled
+// with their reason when they have one. Children are grouped in
+// a cluster under their parent and linked to it with a dashed edge, shared
+// items are only clustered under their first parent. The tree does not need to
+// be built first.
+func (d *DependencyTreeService[T]) WriteDOT(w io.Writer, opts DOTOptions) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	graphName := opts.GraphName
+	if graphName == "" {
+		graphName = "dependencies"
+	}
+
+	roots, children := d.hierarchy()
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(graphName)))
+	if opts.RankDir != "" {
+		sb.WriteString(fmt.Sprintf("  rankdir=%s;\n", dotQuote(opts.RankDir)))
+	}
+	sb.WriteString("  node [shape=box];\n")
+
+	for _, item := range roots {
+		d.writeDOTNode(&sb, item, children, opts, 1)
+	}
+
+	d.forEachEdge(func(item *DependencyTreeItem[T], other *DependencyTreeItem[T], edge Edge) {
+		attributes := []string{}
+		switch edge.Kind {
+		case OptionalEdge:
+			attributes = append(attributes, "style=dotted")
+		case AfterEdge, BeforeEdge:
+			attributes = append(attributes, "color=gray", "arrowhead=empty")
+		}
+		if reason := edge.Reason(); reason != "" {
+			attributes = append(attributes, "label="+dotQuote(reason))
+		}
+
+		if len(attributes) == 0 {
+			sb.WriteString(fmt.Sprintf("  %s -> %s;\n", dotQuote(item.ID), dotQuote(other.ID)))
+			return
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s [%s];\n", dotQuote(item.ID), dotQuote(other.ID), strings.Join(attributes, ", ")))
+	})
+
+	for _, item := range d.flatTree {
+		for _, child := range children[item] {
+			sb.WriteString(fmt.Sprintf("  %s -> %s [style=dashed, arrowhead=none];\n", dotQuote(item.ID), dotQuote(child.ID)))
+		}
+	}
+
+	for _, item := range d.flatTree {
+		for _, parent := range d.additionalParents(item) {
+			sb.WriteString(fmt.Sprintf("  %s -> %s [style=dashed, arrowhead=none];\n", dotQuote(parent.ID), dotQuote(item.ID)))
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (d *DependencyTreeService[T]) writeDOTNode(sb *strings.Builder, item *DependencyTreeItem[T], children map[*DependencyTreeItem[T]][]*DependencyTreeItem[T], opts DOTOptions, level int) {
+	indent := strings.Repeat("  ", level)
+	node := fmt.Sprintf("%s%s [%s];\n", indent, dotQuote(item.ID), strings.Join(dotAttributes(item, opts.Label), ", "))
+
+	if len(children[item]) == 0 {
+		sb.WriteString(node)
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, dotQuote("cluster_"+item.ID)))
+	sb.WriteString(fmt.Sprintf("%s  label=%s;\n", indent, dotQuote(nodeLabel(item, opts.Label))))
+	sb.WriteString("  " + node)
+	for _, child := range children[item] {
+		d.writeDOTNode(sb, child, children, opts, level+1)
+	}
+	sb.WriteString(fmt.Sprintf("%s}\n", indent))
+}
+
+func nodeLabel[T interface{}](item *DependencyTreeItem[T], label NodeLabel) string {
+	if label == LabelByID {
+		return item.ID
+	}
+
+	return item.Name
+}
+
+// dotAttributes returns the label of the item followed by the attributes from
+// its metadata, sorted by name so the output is stable.
+func dotAttributes[T interface{}](item *DependencyTreeItem[T], label NodeLabel) []string {
+	attributes := map[string]string{}
+	switch value := item.GetProperty(DOTAttributesProperty, nil).(type) {
+	case map[string]string:
+		for key, attribute := range value {
+			attributes[key] = attribute
+		}
+	case map[string]interface{}:
+		for key, attribute := range value {
+			attributes[key] = fmt.Sprintf("%v", attribute)
+		}
+	}
+
+	if _, ok := attributes["label"]; !ok {
+		attributes["label"] = nodeLabel(item, label)
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		if key != "label" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := []string{fmt.Sprintf("label=%s", dotQuote(attributes["label"]))}
+	for _, key := range keys {
+		result = append(result, fmt.Sprintf("%s=%s", dotID(key), dotQuote(attributes[key])))
+	}
+
+	return result
+}
+
+// dotID returns the value as it is when it is a valid DOT identifier, quoted
+// otherwise.
+func dotID(value string) string {
+	valid := value != ""
+	for idx, r := range value {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (idx > 0 && r >= '0' && r <= '9')) {
+			valid = false
+			break
+		}
+	}
+	if valid {
+		return value
+	}
+
+	return dotQuote(value)
+}
+
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
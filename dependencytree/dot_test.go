@@ -0,0 +1,172 @@
+package dependencytree
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteDOT(t *testing.T) {
+	t.Run("Writes both kinds of edge and clusters children", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		handlers, _ := service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddItem("users", "Users", "handlers", MockObject1{id: "users"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("users", "database")
+		_ = api.SetProperty(DOTAttributesProperty, map[string]string{"color": "blue", "shape": "component"})
+		_ = handlers.SetProperty(DOTAttributesProperty, map[string]interface{}{"label": "HTTP \"handlers\""})
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, `digraph "dependencies" {
+  node [shape=box];
+  "database" [label="Database"];
+  subgraph "cluster_api" {
+    label="API";
+    "api" [label="API", color="blue", shape="component"];
+    subgraph "cluster_handlers" {
+      label="Handlers";
+      "handlers" [label="HTTP \"handlers\""];
+      "users" [label="Users"];
+    }
+  }
+  "api" -> "database";
+  "users" -> "database";
+  "api" -> "handlers" [style=dashed, arrowhead=none];
+  "handlers" -> "users" [style=dashed, arrowhead=none];
+}
+`, buffer.String())
+	})
+
+	t.Run("Optional dependencies are dotted", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.DependsOnOptional("api", "cache")
+		_ = service.DependsOnOptional("api", "missing")
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{})
+
+		require.NoError(t, err)
+		assert.Contains(t, buffer.String(), "  \"api\" -> \"cache\" [style=dotted];\n")
+		assert.NotContains(t, buffer.String(), "missing")
+	})
+
+	t.Run("Ordering hints are gray edges to the item started first", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("metrics", "Metrics", MockObject1{id: "metrics"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		require.NoError(t, service.AddEdge(Edge{From: "metrics", To: "api", Kind: AfterEdge, Metadata: map[string]interface{}{EdgeReasonProperty: "scrapes the api"}}))
+		_ = service.Before("database", "api")
+		_ = service.After("api", "missing")
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{})
+
+		require.NoError(t, err)
+		assert.Contains(t, buffer.String(), "  \"metrics\" -> \"api\" [color=gray, arrowhead=empty, label=\"scrapes the api\"];\n")
+		assert.Contains(t, buffer.String(), "  \"api\" -> \"database\" [color=gray, arrowhead=empty];\n")
+		assert.NotContains(t, buffer.String(), "missing")
+	})
+
+	t.Run("Attribute names that are not identifiers are quoted", func(t *testing.T) {
+		service := New[MockObject1]()
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = api.SetProperty(DOTAttributesProperty, map[string]interface{}{"fill color": "blue", "penwidth": 2})
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{})
+
+		require.NoError(t, err)
+		assert.Contains(t, buffer.String(), "  \"api\" [label=\"API\", \"fill color\"=\"blue\", penwidth=\"2\"];\n")
+	})
+
+	t.Run("Shared items are linked to every parent", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		handlers, _ := service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddItem("users", "Users", "handlers", MockObject1{id: "users"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("users", "database")
+		_ = api.SetProperty(DOTAttributesProperty, map[string]string{"color": "blue", "shape": "component"})
+		_ = handlers.SetProperty(DOTAttributesProperty, map[string]interface{}{"label": "HTTP \"handlers\""})
+		_ = service.AddParent("users", "api")
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{})
+
+		require.NoError(t, err)
+		assert.Contains(t, buffer.String(), "  \"handlers\" -> \"users\" [style=dashed, arrowhead=none];\n  \"api\" -> \"users\" [style=dashed, arrowhead=none];\n")
+		assert.Equal(t, 1, strings.Count(buffer.String(), "\"users\" [label="))
+	})
+
+	t.Run("Output does not change after build", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		handlers, _ := service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddItem("users", "Users", "handlers", MockObject1{id: "users"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("users", "database")
+		_ = api.SetProperty(DOTAttributesProperty, map[string]string{"color": "blue", "shape": "component"})
+		_ = handlers.SetProperty(DOTAttributesProperty, map[string]interface{}{"label": "HTTP \"handlers\""})
+		before := bytes.Buffer{}
+		after := bytes.Buffer{}
+
+		require.NoError(t, service.WriteDOT(&before, DOTOptions{}))
+		_, err := service.Build()
+		require.NoError(t, err)
+		require.NoError(t, service.WriteDOT(&after, DOTOptions{}))
+
+		assert.Equal(t, before.String(), after.String())
+	})
+
+	t.Run("Labels by id with options", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		buffer := bytes.Buffer{}
+
+		err := service.WriteDOT(&buffer, DOTOptions{GraphName: "services", Label: LabelByID, RankDir: "LR"})
+
+		require.NoError(t, err)
+		assert.Equal(t, `digraph "services" {
+  rankdir="LR";
+  node [shape=box];
+  "item_1" [label="item_1"];
+}
+`, buffer.String())
+	})
+
+	t.Run("Returns the writer error", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		handlers, _ := service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddItem("users", "Users", "handlers", MockObject1{id: "users"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("users", "database")
+		_ = api.SetProperty(DOTAttributesProperty, map[string]string{"color": "blue", "shape": "component"})
+		_ = handlers.SetProperty(DOTAttributesProperty, map[string]interface{}{"label": "HTTP \"handlers\""})
+
+		err := service.WriteDOT(failingWriter{}, DOTOptions{})
+
+		assert.EqualError(t, err, "write failed")
+	})
+}
@@ -0,0 +1,312 @@
+package dependencytree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EdgeKind tells why an item comes after another one.
+type EdgeKind string
+
+const (
+	// ExplicitEdge is a dependency added with DependsOn.
+	ExplicitEdge EdgeKind = "depends-on"
+	// OptionalEdge is a dependency added with DependsOnOptional.
+	OptionalEdge EdgeKind = "optional"
+	// AfterEdge is an ordering hint added with After.
+	AfterEdge EdgeKind = "after"
+	// BeforeEdge is an ordering hint added with Before. In a DependencyPath it
+	// was declared by the item the edge goes to.
+	BeforeEdge EdgeKind = "before"
+	// ParentEdge is the dependency of a child on one of its parents that Build
+	// adds.
+	ParentEdge EdgeKind = "parent"
+)
+
+// EdgeReasonProperty is the metadata key holding why an edge exists, the
+// diagrams write it as the label of the edge.
+const EdgeReasonProperty = "reason"
+
+// Edge is a relationship declared by the From item on the To item, To holds
+// the id or name it was declared with. Weight and Metadata are only set on the
+// edges added or annotated with AddEdge.
+type Edge struct {
+	From     string                 `json:"from" yaml:"from"`
+	To       string                 `json:"to" yaml:"to"`
+	Kind     EdgeKind               `json:"kind" yaml:"kind"`
+	Weight   float64                `json:"weight,omitempty" yaml:"weight"`
+	Metadata map[string]interface{} `json:"metadata,omitempty" yaml:"metadata"`
+	// caseInsensitive follows the setting of the service the edge comes from,
+	// it is used by the Edges filters.
+	caseInsensitive bool
+}
+
+// Reason returns the EdgeReasonProperty of the edge, or an empty string.
+func (e Edge) Reason() string {
+	if reason, ok := e.Metadata[EdgeReasonProperty].(string); ok {
+		return reason
+	}
+
+	return ""
+}
+
+func (e Edge) String() string {
+	return fmt.Sprintf("%s -%s-> %s", e.From, string(e.Kind), e.To)
+}
+
+func (e Edge) equal(a, b string) bool {
+	if e.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+func (e Edge) isAnnotated() bool {
+	return e.Weight != 0 || len(e.Metadata) > 0
+}
+
+type Edges []Edge
+
+func (e Edges) WithKind(kinds ...EdgeKind) Edges {
+	result := Edges{}
+	for _, edge := range e {
+		for _, kind := range kinds {
+			if edge.Kind == kind {
+				result = append(result, edge)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// From returns the edges declared by the item with the id.
+func (e Edges) From(id string) Edges {
+	result := Edges{}
+	for _, edge := range e {
+		if edge.equal(edge.From, id) {
+			result = append(result, edge)
+		}
+	}
+
+	return result
+}
+
+// To returns the edges on the item with the id or name.
+func (e Edges) To(idOrName string) Edges {
+	result := Edges{}
+	for _, edge := range e {
+		if edge.equal(edge.To, idOrName) {
+			result = append(result, edge)
+		}
+	}
+
+	return result
+}
+
+type edgeKey struct {
+	from string
+	to   string
+	kind EdgeKind
+}
+
+func (d *DependencyTreeService[T]) edgeKey(from string, to string, kind EdgeKind) edgeKey {
+	return edgeKey{from: d.key(from), to: d.key(to), kind: kind}
+}
+
+// AddEdge adds an edge of any kind but ParentEdge, which is added with
+// AddParent, together with its weight and metadata. When the From item already
+// declares the edge only its weight and metadata are replaced.
+func (d *DependencyTreeService[T]) AddEdge(edge Edge) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(edge.From)
+	if item == nil {
+		return fmt.Errorf("item %v not found", edge.From)
+	}
+
+	to := edge.To
+	if other := d.getItem(edge.To); other != nil {
+		to = other.ID
+	}
+
+	declared, err := d.declaredEdge(item, to, edge.Kind)
+	if err != nil {
+		return err
+	}
+	if declared == "" {
+		if err := d.addEdge(item, to, edge.Kind); err != nil {
+			return err
+		}
+		declared = to
+	}
+
+	if d.edges == nil {
+		d.edges = make(map[edgeKey]Edge)
+	}
+	d.edges[d.edgeKey(item.ID, declared, edge.Kind)] = Edge{
+		From:     item.ID,
+		To:       declared,
+		Kind:     edge.Kind,
+		Weight:   edge.Weight,
+		Metadata: edge.Metadata,
+	}
+	d.changed()
+
+	return nil
+}
+
+// declaredEdge returns how the item declared the edge, or an empty string if it
+// does not declare it.
+func (d *DependencyTreeService[T]) declaredEdge(item *DependencyTreeItem[T], to string, kind EdgeKind) (string, error) {
+	declared, err := edgeTargets(item, kind)
+	if err != nil {
+		return "", err
+	}
+
+	target := d.getItem(to)
+	for _, other := range declared {
+		if d.equal(other, to) || (target != nil && d.getItem(other) == target) {
+			return other, nil
+		}
+	}
+
+	return "", nil
+}
+
+// edgeTargets returns the items the item declared edges of the kind on.
+func edgeTargets[T interface{}](item *DependencyTreeItem[T], kind EdgeKind) ([]string, error) {
+	switch kind {
+	case ExplicitEdge:
+		return item.IsDependentOn(), nil
+	case OptionalEdge:
+		return item.OptionalDependencies(), nil
+	case AfterEdge:
+		return item.OrderedAfter(), nil
+	case BeforeEdge:
+		return item.OrderedBefore(), nil
+	case ParentEdge:
+		return nil, fmt.Errorf("edges of kind %s are added with AddParent", kind)
+	default:
+		return nil, fmt.Errorf("unknown edge kind %s", kind)
+	}
+}
+
+// edgeAnnotations matches the weight and metadata of the edges to the edges
+// declared by the items, the caller must hold the lock.
+func (d *DependencyTreeService[T]) edgeAnnotations(items []*DependencyTreeItem[T], edges Edges) (map[edgeKey]Edge, error) {
+	result := make(map[edgeKey]Edge, len(edges))
+	for _, edge := range edges {
+		idx := slices.IndexFunc(items, func(item *DependencyTreeItem[T]) bool {
+			return d.equal(item.ID, edge.From)
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("edge %s: item %s not found", edge, edge.From)
+		}
+
+		declared, err := edgeTargets(items[idx], edge.Kind)
+		if err != nil {
+			return nil, fmt.Errorf("edge %s: %w", edge, err)
+		}
+
+		to := slices.IndexFunc(declared, func(other string) bool {
+			return d.equal(other, edge.To)
+		})
+		if to < 0 {
+			return nil, fmt.Errorf("edge %s is not declared by item %s", edge, edge.From)
+		}
+
+		edge.From = items[idx].ID
+		edge.To = declared[to]
+		result[d.edgeKey(edge.From, edge.To, edge.Kind)] = edge
+	}
+
+	return result, nil
+}
+
+// addEdge declares a new edge on the item, pointing to the id of the other item
+// when it exists. The caller must hold the write lock.
+func (d *DependencyTreeService[T]) addEdge(item *DependencyTreeItem[T], to string, kind EdgeKind) error {
+	dependency := d.getItem(to)
+	if dependency != nil {
+		to = dependency.ID
+	}
+
+	switch kind {
+	case ExplicitEdge:
+		if dependency == nil {
+			return fmt.Errorf("dependency %v not found", to)
+		}
+		if err := item.DependsOn(to); err != nil {
+			return err
+		}
+		dependency.AddRequiredBy(item.ID)
+	case OptionalEdge:
+		if err := item.DependsOnOptional(to); err != nil {
+			return err
+		}
+	case AfterEdge:
+		if err := item.After(to); err != nil {
+			return err
+		}
+	case BeforeEdge:
+		if err := item.Before(to); err != nil {
+			return err
+		}
+	}
+
+	d.changed()
+	return nil
+}
+
+// Edges returns every edge of the items, including the edges on their parents,
+// with the weight and metadata set with AddEdge.
+func (d *DependencyTreeService[T]) Edges() Edges {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.allEdges()
+}
+
+func (d *DependencyTreeService[T]) allEdges() Edges {
+	result := Edges{}
+	for _, item := range d.flatTree {
+		parents := d.itemParents(item)
+		for _, parent := range parents {
+			result = append(result, Edge{From: item.ID, To: parent.ID, Kind: ParentEdge, caseInsensitive: d.caseInsensitive})
+		}
+
+		for _, dependency := range item.IsDependentOn() {
+			// Build adds the parents as dependencies
+			if dependencyItem := d.getItem(dependency); dependencyItem == nil || !slices.Contains(parents, dependencyItem) {
+				result = append(result, d.edge(item, dependency, ExplicitEdge))
+			}
+		}
+		for _, dependency := range item.OptionalDependencies() {
+			result = append(result, d.edge(item, dependency, OptionalEdge))
+		}
+		for _, other := range item.OrderedAfter() {
+			result = append(result, d.edge(item, other, AfterEdge))
+		}
+		for _, other := range item.OrderedBefore() {
+			result = append(result, d.edge(item, other, BeforeEdge))
+		}
+	}
+
+	return result
+}
+
+// edge returns the edge declared by the item, with its weight and metadata.
+func (d *DependencyTreeService[T]) edge(item *DependencyTreeItem[T], to string, kind EdgeKind) Edge {
+	edge, ok := d.edges[d.edgeKey(item.ID, to, kind)]
+	if !ok {
+		edge = Edge{From: item.ID, To: to, Kind: kind}
+	}
+	edge.caseInsensitive = d.caseInsensitive
+
+	return edge
+}
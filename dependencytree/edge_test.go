@@ -0,0 +1,140 @@
+package dependencytree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddEdge(t *testing.T) {
+	t.Run("Annotates a declared edge", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+
+		err := service.AddEdge(Edge{From: "API", To: "Database", Kind: ExplicitEdge, Weight: 2, Metadata: map[string]interface{}{EdgeReasonProperty: "stores sessions"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database"}, service.GetItem("api").IsDependentOn())
+		edges := service.Edges().From("api")
+		require.Len(t, edges, 1)
+		assert.Equal(t, 2.0, edges[0].Weight)
+		assert.Equal(t, "stores sessions", edges[0].Reason())
+	})
+
+	t.Run("Declares a new edge", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+
+		require.NoError(t, service.AddEdge(Edge{From: "database", To: "logging", Kind: AfterEdge, Weight: 1}))
+		require.NoError(t, service.AddEdge(Edge{From: "handlers", To: "database", Kind: ExplicitEdge}))
+
+		assert.Equal(t, []string{"logging"}, service.GetItem("database").OrderedAfter())
+		assert.Equal(t, []string{"database"}, service.GetItem("handlers").IsDependentOn())
+		assert.Contains(t, service.GetItem("database").RequiredBy(), "handlers")
+	})
+
+	t.Run("Returns errors", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+
+		assert.EqualError(t, service.AddEdge(Edge{From: "missing", To: "api", Kind: ExplicitEdge}), "item missing not found")
+		assert.EqualError(t, service.AddEdge(Edge{From: "api", To: "missing", Kind: ExplicitEdge}), "dependency missing not found")
+		assert.EqualError(t, service.AddEdge(Edge{From: "handlers", To: "api", Kind: ParentEdge}), "edges of kind parent are added with AddParent")
+		assert.EqualError(t, service.AddEdge(Edge{From: "api", To: "database", Kind: "requires"}), "unknown edge kind requires")
+	})
+}
+
+func TestEdges(t *testing.T) {
+	t.Run("Lists every edge", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		edges := service.Edges()
+
+		assert.Equal(t, Edges{
+			{From: "api", To: "database", Kind: ExplicitEdge},
+			{From: "handlers", To: "api", Kind: ParentEdge},
+			{From: "handlers", To: "cache", Kind: OptionalEdge},
+			{From: "handlers", To: "database", Kind: AfterEdge},
+		}, edges)
+	})
+
+	t.Run("Filters edges", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+		edges := service.Edges()
+
+		assert.Len(t, edges.WithKind(ExplicitEdge, AfterEdge), 2)
+		assert.Len(t, edges.From("handlers"), 3)
+		assert.Len(t, edges.To("database"), 2)
+		assert.Empty(t, edges.WithKind(BeforeEdge))
+	})
+
+	t.Run("Removed items lose their annotations", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+		require.NoError(t, service.AddEdge(Edge{From: "api", To: "database", Kind: ExplicitEdge, Weight: 2}))
+		api := service.GetItem("api")
+
+		require.NoError(t, service.RemoveDependencyTreeItem(api))
+		require.NoError(t, service.AddDependencyTreeItem(api))
+
+		assert.Equal(t, 0.0, service.Edges().From("api")[0].Weight)
+	})
+
+	t.Run("Diagrams use the reason as label", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOnOptional("handlers", "cache")
+		_ = service.After("handlers", "database")
+		require.NoError(t, service.AddEdge(Edge{From: "api", To: "database", Kind: ExplicitEdge, Metadata: map[string]interface{}{EdgeReasonProperty: "stores sessions"}}))
+		dot := bytes.Buffer{}
+		mermaid := bytes.Buffer{}
+		plantUML := bytes.Buffer{}
+
+		require.NoError(t, service.WriteDOT(&dot, DOTOptions{}))
+		require.NoError(t, service.WriteMermaid(&mermaid, MermaidOptions{}))
+		require.NoError(t, service.WritePlantUML(&plantUML, PlantUMLOptions{}))
+
+		assert.Contains(t, dot.String(), "  \"api\" -> \"database\" [label=\"stores sessions\"];\n")
+		assert.Contains(t, mermaid.String(), "  n_api -->|\"stores sessions\"| n_database\n")
+		assert.Contains(t, plantUML.String(), "n_api --> n_database : stores sessions\n")
+	})
+}
@@ -0,0 +1,440 @@
+package dependencytree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecutorFunc is called by the Executor once for every item of the tree.
+type ExecutorFunc[T interface{}] func(ctx context.Context, item *DependencyTreeItem[T]) error
+
+type ExecutionStatus string
+
+const (
+	ExecutionPending   ExecutionStatus = "pending"
+	ExecutionSucceeded ExecutionStatus = "succeeded"
+	ExecutionFailed    ExecutionStatus = "failed"
+	ExecutionSkipped   ExecutionStatus = "skipped"
+	ExecutionCancelled ExecutionStatus = "cancelled"
+)
+
+// ExecutionMode decides in which direction the Executor walks the tree.
+type ExecutionMode string
+
+const (
+	// StartupMode runs an item after every item it depends on.
+	StartupMode ExecutionMode = "startup"
+	// ShutdownMode runs an item after every item that requires it, including
+	// its children, in the order returned by BuildTeardown.
+	ShutdownMode ExecutionMode = "shutdown"
+)
+
+// TimeoutProperty is the metadata key used to override the item timeout of the
+// Executor for a single item, it accepts a time.Duration or a duration string.
+const TimeoutProperty = "timeout"
+
+// FailurePolicy decides what the Executor does with the remaining items when
+// one of them fails.
+type FailurePolicy string
+
+const (
+	// FailFast cancels the running items and does not start any other item.
+	FailFast FailurePolicy = "fail-fast"
+	// SkipDependents skips every item that directly or transitively requires
+	// the failed item and keeps running the others.
+	SkipDependents FailurePolicy = "skip-dependents"
+	// ContinueOnFailure ignores the failure and keeps running every item.
+	ContinueOnFailure FailurePolicy = "continue"
+)
+
+type ExecutionResult[T interface{}] struct {
+	Item       *DependencyTreeItem[T]
+	Status     ExecutionStatus
+	Error      error
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+func (r *ExecutionResult[T]) Duration() time.Duration {
+	if r.StartedAt.IsZero() || r.FinishedAt.IsZero() {
+		return 0
+	}
+
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// ExecutionReport holds the result of every item, in the order returned by Build,
+// or by BuildTeardown when running in shutdown mode.
+type ExecutionReport[T interface{}] struct {
+	Results []*ExecutionResult[T]
+	// caseInsensitive is the setting of the service when the items were run.
+	caseInsensitive bool
+}
+
+func (r *ExecutionReport[T]) Get(nameOrId string) *ExecutionResult[T] {
+	for _, result := range r.Results {
+		if r.equal(result.Item.ID, nameOrId) || r.equal(result.Item.Name, nameOrId) {
+			return result
+		}
+	}
+
+	return nil
+}
+
+func (r *ExecutionReport[T]) equal(a, b string) bool {
+	if r.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+func (r *ExecutionReport[T]) WithStatus(status ExecutionStatus) []*ExecutionResult[T] {
+	result := []*ExecutionResult[T]{}
+	for _, item := range r.Results {
+		if item.Status == status {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// Executor runs a function for every item of a DependencyTreeService, starting
+// an item as soon as all of the items it depends on have finished.
+type Executor[T interface{}] struct {
+	service        *DependencyTreeService[T]
+	fn             ExecutorFunc[T]
+	maxConcurrency int
+	failurePolicy  FailurePolicy
+	mode           ExecutionMode
+	itemTimeout    time.Duration
+}
+
+func NewExecutor[T interface{}](service *DependencyTreeService[T], fn ExecutorFunc[T]) *Executor[T] {
+	return &Executor[T]{
+		service:        service,
+		fn:             fn,
+		maxConcurrency: 0,
+		failurePolicy:  FailFast,
+		mode:           StartupMode,
+		itemTimeout:    0,
+	}
+}
+
+func (e *Executor[T]) MaxConcurrency() int {
+	return e.maxConcurrency
+}
+
+// SetMaxConcurrency limits how many items can run at the same time, zero or
+// less means there is no limit.
+func (e *Executor[T]) SetMaxConcurrency(maxConcurrency int) {
+	e.maxConcurrency = maxConcurrency
+}
+
+func (e *Executor[T]) FailurePolicy() FailurePolicy {
+	return e.failurePolicy
+}
+
+func (e *Executor[T]) SetFailurePolicy(policy FailurePolicy) {
+	e.failurePolicy = policy
+}
+
+func (e *Executor[T]) Mode() ExecutionMode {
+	return e.mode
+}
+
+func (e *Executor[T]) SetMode(mode ExecutionMode) {
+	e.mode = mode
+}
+
+func (e *Executor[T]) ItemTimeout() time.Duration {
+	return e.itemTimeout
+}
+
+// SetItemTimeout sets how long every item can run before it is reported as
+// failed, zero or less means there is no timeout. Items can override it with
+// the TimeoutProperty metadata.
+func (e *Executor[T]) SetItemTimeout(timeout time.Duration) {
+	e.itemTimeout = timeout
+}
+
+type executionDone struct {
+	index int
+	err   error
+}
+
+// Run builds the tree and executes every item. What happens when an item fails
+// depends on the failure policy. When the context is cancelled no other item is
+// started and the items that did not run are reported as cancelled. The
+// returned error joins every failure.
+func (e *Executor[T]) Run(ctx context.Context) (*ExecutionReport[T], error) {
+	if e.fn == nil {
+		return nil, fmt.Errorf("executor function must not be nil")
+	}
+
+	graph, callbacks, err := e.service.buildGraph()
+	if err != nil {
+		return nil, err
+	}
+	runCallbacks(callbacks)
+
+	// in shutdown mode an item waits for the items that require it, so we
+	// walk the same graph with the edges reversed
+	order := make([]int, len(graph.items))
+	for idx := range order {
+		order[idx] = idx
+	}
+	if e.mode == ShutdownMode {
+		graph = graph.reversed()
+		slices.Reverse(order)
+	}
+
+	report := ExecutionReport[T]{
+		Results:         make([]*ExecutionResult[T], len(graph.items)),
+		caseInsensitive: e.service.IsCaseInsensitive(),
+	}
+	pending := make([]int, len(graph.items))
+	ready := []int{}
+	for _, idx := range order {
+		report.Results[idx] = &ExecutionResult[T]{
+			Item:   graph.items[idx],
+			Status: ExecutionPending,
+		}
+		pending[idx] = len(graph.dependencies[idx])
+		if pending[idx] == 0 {
+			ready = append(ready, idx)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := []error{}
+	done := make(chan executionDone)
+	wg := sync.WaitGroup{}
+	running := 0
+	stopping := false
+
+	for {
+		// the context can be cancelled before Run or while waiting for an item
+		if runCtx.Err() != nil {
+			stopping = true
+		}
+
+		for !stopping && len(ready) > 0 && (e.maxConcurrency <= 0 || running < e.maxConcurrency) {
+			idx := ready[0]
+			ready = ready[1:]
+			running += 1
+			report.Results[idx].StartedAt = time.Now()
+			e.service.printVerbosef("Starting %s", graph.items[idx].Name)
+
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				done <- executionDone{index: idx, err: e.execute(runCtx, graph.items[idx])}
+			}(idx)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		var result executionDone
+		if stopping {
+			result = <-done
+		} else {
+			select {
+			case <-runCtx.Done():
+				stopping = true
+				continue
+			case result = <-done:
+			}
+		}
+
+		running -= 1
+		item := report.Results[result.index]
+		e.finish(runCtx, item, result.err)
+
+		if item.Status == ExecutionFailed {
+			errs = append(errs, fmt.Errorf("item %s failed: %w", item.Item.Name, item.Error))
+
+			switch e.failurePolicy {
+			case SkipDependents:
+				e.skipDependents(graph, report, item)
+			case ContinueOnFailure:
+				e.service.printVerbosef("Continuing after %s failed", item.Item.Name)
+			default:
+				stopping = true
+				cancel()
+				continue
+			}
+		}
+
+		if item.Status == ExecutionSucceeded || item.Status == ExecutionFailed {
+			ready = append(ready, e.release(graph, report, pending, result.index)...)
+		}
+	}
+
+	wg.Wait()
+
+	// the last item can finish before the cancellation is noticed, so the
+	// error of the context is checked once every item is done
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	for _, result := range report.Results {
+		if result.Status == ExecutionPending {
+			result.Status = ExecutionCancelled
+		}
+	}
+
+	if e.mode == ShutdownMode {
+		slices.Reverse(report.Results)
+	}
+
+	return &report, errors.Join(errs...)
+}
+
+// release lets the items waiting on a finished item know it is done and returns
+// the ones that can start. Skipped items never run, so the items waiting on them
+// are released right away, which only happens to items ordered after them with
+// After or Before.
+func (e *Executor[T]) release(graph *dependencyGraph[T], report ExecutionReport[T], pending []int, finished int) []int {
+	result := []int{}
+	queue := []int{finished}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dependent := range graph.dependents[current] {
+			pending[dependent] -= 1
+			if pending[dependent] != 0 {
+				continue
+			}
+
+			switch report.Results[dependent].Status {
+			case ExecutionPending:
+				result = append(result, dependent)
+			case ExecutionSkipped:
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return result
+}
+
+// skipDependents marks every pending item that requires the failed item, walking
+// the requiredBy relationships, as skipped. In shutdown mode it walks the items
+// the failed item depends on instead. Items that only depend optionally on the
+// failed item, or are ordered after it with After or Before, are not skipped.
+func (e *Executor[T]) skipDependents(graph *dependencyGraph[T], report ExecutionReport[T], failed *ExecutionResult[T]) {
+	queue := []*DependencyTreeItem[T]{failed.Item}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		next := current.RequiredBy()
+		if e.mode == ShutdownMode {
+			next = current.IsDependentOn()
+		}
+
+		for _, id := range next {
+			// items added or removed since the graph was built are not run
+			dependent := e.service.GetItem(id)
+			position, ok := graph.positions[dependent]
+			if !ok {
+				continue
+			}
+
+			result := report.Results[position]
+			if result.Status != ExecutionPending {
+				continue
+			}
+
+			e.service.printVerbosef("Skipping %s as %s failed", dependent.Name, failed.Item.Name)
+			result.Status = ExecutionSkipped
+			result.Error = fmt.Errorf("item %s was skipped because %s failed", dependent.Name, failed.Item.Name)
+			queue = append(queue, dependent)
+		}
+	}
+}
+
+// execute calls the executor function for the item, giving up on it once its
+// timeout expires even if the function does not honor the context.
+func (e *Executor[T]) execute(ctx context.Context, item *DependencyTreeItem[T]) error {
+	timeout := e.timeoutFor(item)
+	if timeout <= 0 {
+		return e.call(ctx, item)
+	}
+
+	itemCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- e.call(itemCtx, item)
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil && ctx.Err() == nil && errors.Is(itemCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("item %s did not finish within %s: %w", item.Name, timeout.String(), err)
+		}
+		return err
+	case <-itemCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("item %s did not finish within %s: %w", item.Name, timeout.String(), itemCtx.Err())
+	}
+}
+
+func (e *Executor[T]) call(ctx context.Context, item *DependencyTreeItem[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("item %s panicked: %v", item.Name, r)
+		}
+	}()
+
+	return e.fn(ctx, item)
+}
+
+func (e *Executor[T]) timeoutFor(item *DependencyTreeItem[T]) time.Duration {
+	switch value := item.GetProperty(TimeoutProperty, nil).(type) {
+	case time.Duration:
+		return value
+	case string:
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			e.service.printVerbosef("Ignoring invalid timeout %s of item %s", value, item.Name)
+			return e.itemTimeout
+		}
+		return timeout
+	default:
+		return e.itemTimeout
+	}
+}
+
+func (e *Executor[T]) finish(ctx context.Context, result *ExecutionResult[T], err error) {
+	result.FinishedAt = time.Now()
+	result.Error = err
+
+	switch {
+	case err == nil:
+		result.Status = ExecutionSucceeded
+	case ctx.Err() != nil:
+		result.Status = ExecutionCancelled
+	default:
+		result.Status = ExecutionFailed
+	}
+
+	e.service.printVerbosef("Item %s finished as %s in %s", result.Item.Name, string(result.Status), result.Duration().String())
+}
@@ -0,0 +1,523 @@
+package dependencytree
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorRun(t *testing.T) {
+	t.Run("Runs every item after its dependencies", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		lock := sync.Mutex{}
+		finished := map[string]bool{}
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			lock.Lock()
+			defer lock.Unlock()
+			for _, dependency := range item.IsDependentOn() {
+				if !finished[service.GetItem(dependency).ID] {
+					return errors.New("dependency " + dependency + " did not finish before " + item.ID)
+				}
+			}
+			value := item.Value()
+			finished[value.ID()] = true
+			return nil
+		})
+
+		report, err := executor.Run(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, report.Results, 5)
+		assert.Len(t, report.WithStatus(ExecutionSucceeded), 5)
+		assert.Len(t, finished, 5)
+		assert.Equal(t, ExecutionSucceeded, report.Get("gateway").Status)
+	})
+
+	t.Run("Runs independent items concurrently", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		started := make(chan struct{})
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			switch item.ID {
+			case "database":
+				<-started
+			case "cache":
+				close(started)
+			}
+			return nil
+		})
+
+		report, err := executor.Run(context.Background())
+
+		require.NoError(t, err)
+		assert.Len(t, report.WithStatus(ExecutionSucceeded), 5)
+	})
+
+	t.Run("Respects the max concurrency", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		var running, highest int32
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				previous := atomic.LoadInt32(&highest)
+				if current <= previous || atomic.CompareAndSwapInt32(&highest, previous, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		executor.SetMaxConcurrency(2)
+
+		report, err := executor.Run(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, executor.MaxConcurrency())
+		assert.Len(t, report.WithStatus(ExecutionSucceeded), 6)
+		assert.LessOrEqual(t, atomic.LoadInt32(&highest), int32(2))
+	})
+
+	t.Run("Stops when an item fails", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		failure := errors.New("connection refused")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "database" {
+				return failure
+			}
+			return nil
+		})
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionFailed, report.Get("database").Status)
+		assert.Equal(t, failure, report.Get("database").Error)
+		assert.Equal(t, ExecutionCancelled, report.Get("api").Status)
+		assert.Equal(t, ExecutionCancelled, report.Get("api handlers").Status)
+		assert.Equal(t, ExecutionCancelled, report.Get("gateway").Status)
+	})
+
+	t.Run("Recovers from a panic", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "cache" {
+				panic("boom")
+			}
+			return nil
+		})
+
+		report, err := executor.Run(context.Background())
+
+		require.EqualError(t, err, "item cache failed: item cache panicked: boom")
+		assert.Equal(t, ExecutionFailed, report.Get("cache").Status)
+	})
+
+	t.Run("Honors context cancellation", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		ctx, cancel := context.WithCancel(context.Background())
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "database" {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+
+		report, err := executor.Run(ctx)
+
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, ExecutionCancelled, report.Get("database").Status)
+		assert.Equal(t, ExecutionCancelled, report.Get("api").Status)
+		assert.Equal(t, ExecutionCancelled, report.Get("gateway").Status)
+	})
+
+	t.Run("Starts nothing with a cancelled context", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var calls int32
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+
+		report, err := executor.Run(ctx)
+
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, atomic.LoadInt32(&calls))
+		assert.Len(t, report.WithStatus(ExecutionCancelled), 2)
+	})
+
+	t.Run("Fails without a function", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		executor := NewExecutor[MockObject1](service, nil)
+
+		report, err := executor.Run(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
+
+func TestExecutorFailurePolicy(t *testing.T) {
+	failure := errors.New("connection refused")
+	failDatabase := func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+		if item.ID == "database" {
+			return failure
+		}
+		return nil
+	}
+
+	t.Run("Fail fast is the default", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		executor := NewExecutor(service, failDatabase)
+
+		assert.Equal(t, FailFast, executor.FailurePolicy())
+	})
+
+	t.Run("Skip dependents", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		_, _ = service.AddRootItem("metrics", "metrics", MockObject1{id: "metrics"})
+		_ = service.DependsOn("metrics", "cache")
+		executor := NewExecutor(service, failDatabase)
+		executor.SetFailurePolicy(SkipDependents)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionFailed, report.Get("database").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("cache").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("metrics").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("api").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("api handlers").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("gateway").Status)
+		assert.EqualError(t, report.Get("gateway").Error, "item gateway was skipped because database failed")
+	})
+
+	t.Run("Skip dependents declared on the item", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		api, _ := service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_ = api.DependsOn("database")
+		executor := NewExecutor(service, failDatabase)
+		executor.SetFailurePolicy(SkipDependents)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionSkipped, report.Get("api").Status)
+	})
+
+	t.Run("Ordering hints do not skip items", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		_, _ = service.AddRootItem("metrics", "metrics", MockObject1{id: "metrics"})
+		_, _ = service.AddRootItem("audit", "audit", MockObject1{id: "audit"})
+		_ = service.After("metrics", "database")
+		_ = service.Before("gateway", "audit")
+		finished := []string{}
+		lock := sync.Mutex{}
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "database" {
+				return failure
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			finished = append(finished, item.ID)
+			return nil
+		})
+		executor.SetFailurePolicy(SkipDependents)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionSkipped, report.Get("gateway").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("metrics").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("audit").Status)
+		assert.ElementsMatch(t, []string{"cache", "metrics", "audit"}, finished)
+	})
+
+	t.Run("Optional dependencies do not skip items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_ = service.DependsOnOptional("api", "cache")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "cache" {
+				return failure
+			}
+			return nil
+		})
+		executor.SetFailurePolicy(SkipDependents)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionFailed, report.Get("cache").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("api").Status)
+		assert.False(t, report.Get("api").StartedAt.Before(report.Get("cache").FinishedAt))
+	})
+
+	t.Run("Continue on failure", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		_, _ = service.AddRootItem("broken", "broken", MockObject1{id: "broken"})
+		other := errors.New("bad config")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			switch item.ID {
+			case "database":
+				return failure
+			case "broken":
+				return other
+			}
+			return nil
+		})
+		executor.SetFailurePolicy(ContinueOnFailure)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		require.ErrorIs(t, err, other)
+		assert.Equal(t, ExecutionFailed, report.Get("database").Status)
+		assert.Equal(t, ExecutionFailed, report.Get("broken").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("api").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("gateway").Status)
+		assert.Len(t, report.WithStatus(ExecutionSucceeded), 4)
+	})
+}
+
+func TestExecutorShutdown(t *testing.T) {
+	t.Run("Stops every item after the items that require it", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		lock := sync.Mutex{}
+		stopped := map[string]bool{}
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			lock.Lock()
+			defer lock.Unlock()
+			for _, id := range item.RequiredBy() {
+				if !stopped[id] {
+					return errors.New(id + " was not stopped before " + item.ID)
+				}
+			}
+			stopped[item.ID] = true
+			return nil
+		})
+		executor.SetMode(ShutdownMode)
+
+		report, err := executor.Run(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, ShutdownMode, executor.Mode())
+		assert.Len(t, stopped, 5)
+		ids := []string{}
+		for _, result := range report.Results {
+			ids = append(ids, result.Item.ID)
+		}
+		assert.Equal(t, []string{"gateway", "api_handlers", "api", "cache", "database"}, ids)
+	})
+
+	t.Run("Skips the dependencies of an item that failed to stop", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddItem("api_handlers", "api handlers", "api", MockObject1{id: "api_handlers"})
+		_, _ = service.AddRootItem("gateway", "gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("gateway", "api")
+		failure := errors.New("still draining")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "api_handlers" {
+				return failure
+			}
+			return nil
+		})
+		executor.SetMode(ShutdownMode)
+		executor.SetFailurePolicy(SkipDependents)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, ExecutionSucceeded, report.Get("gateway").Status)
+		assert.Equal(t, ExecutionFailed, report.Get("api_handlers").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("api").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("database").Status)
+		assert.Equal(t, ExecutionSkipped, report.Get("cache").Status)
+	})
+
+	t.Run("Items added while running are not skipped", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("database", "database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("worker", "worker", MockObject1{id: "worker"})
+		_ = service.DependsOn("api", "database")
+		failure := errors.New("still draining")
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "worker" {
+				_, _ = service.AddRootItem("queue", "queue", MockObject1{id: "queue"})
+				_ = service.DependsOn("worker", "queue")
+				return failure
+			}
+			return nil
+		})
+		executor.SetMode(ShutdownMode)
+		executor.SetFailurePolicy(SkipDependents)
+		executor.SetMaxConcurrency(1)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, failure)
+		require.Len(t, report.Results, 3)
+		assert.Equal(t, ExecutionFailed, report.Get("worker").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("api").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("database").Status)
+	})
+}
+
+func TestExecutorItemTimeout(t *testing.T) {
+	t.Run("Item that does not finish in time fails", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_, _ = service.AddRootItem("slow", "slow", MockObject1{id: "slow"})
+		_, _ = service.AddRootItem("fast", "fast", MockObject1{id: "fast"})
+		release := make(chan struct{})
+		defer close(release)
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			if item.ID == "slow" {
+				<-release
+			}
+			return nil
+		})
+		executor.SetItemTimeout(20 * time.Millisecond)
+		executor.SetFailurePolicy(ContinueOnFailure)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 20*time.Millisecond, executor.ItemTimeout())
+		assert.Equal(t, ExecutionFailed, report.Get("slow").Status)
+		assert.EqualError(t, report.Get("slow").Error, "item slow did not finish within 20ms: context deadline exceeded")
+		assert.Equal(t, ExecutionSucceeded, report.Get("fast").Status)
+	})
+
+	t.Run("Item timeout from metadata", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		slow, _ := service.AddRootItem("slow", "slow", MockObject1{id: "slow"})
+		_ = slow.SetProperty(TimeoutProperty, "10ms")
+		patient, _ := service.AddRootItem("patient", "patient", MockObject1{id: "patient"})
+		_ = patient.SetProperty(TimeoutProperty, time.Second)
+		executor := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(50 * time.Millisecond):
+				return nil
+			}
+		})
+		executor.SetFailurePolicy(ContinueOnFailure)
+
+		report, err := executor.Run(context.Background())
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, ExecutionFailed, report.Get("slow").Status)
+		assert.Equal(t, ExecutionSucceeded, report.Get("patient").Status)
+	})
+}
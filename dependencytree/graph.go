@@ -0,0 +1,328 @@
+package dependencytree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// dependencyGraph is the resolved view of the flat tree used by Build, where
+// every dependency name or id was replaced by the index of the item it points to.
+// It holds its own copy of the items so it can be used after the lock is released.
+type dependencyGraph[T interface{}] struct {
+	items        []*DependencyTreeItem[T]
+	positions    map[*DependencyTreeItem[T]]int
+	dependencies [][]int
+	dependents   [][]int
+}
+
+func (d *DependencyTreeService[T]) newDependencyGraph() (*dependencyGraph[T], error) {
+	graph := dependencyGraph[T]{
+		items:        make([]*DependencyTreeItem[T], len(d.flatTree)),
+		positions:    make(map[*DependencyTreeItem[T]]int, len(d.flatTree)),
+		dependencies: make([][]int, len(d.flatTree)),
+		dependents:   make([][]int, len(d.flatTree)),
+	}
+
+	copy(graph.items, d.flatTree)
+	for idx, item := range d.flatTree {
+		graph.positions[item] = idx
+	}
+
+	orderedAfter := d.orderedAfter()
+	for idx, item := range d.flatTree {
+		addEdge := func(dependencyItem *DependencyTreeItem[T]) {
+			dependencyIndex := graph.positions[dependencyItem]
+			graph.dependencies[idx] = append(graph.dependencies[idx], dependencyIndex)
+			graph.dependents[dependencyIndex] = append(graph.dependents[dependencyIndex], idx)
+		}
+
+		for _, dependency := range item.IsDependentOn() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil {
+				err := fmt.Errorf("dependency on %s of service %s was not found in the context configuration", dependency, item.Name)
+				return nil, err
+			}
+
+			addEdge(dependencyItem)
+		}
+
+		for _, dependency := range item.OptionalDependencies() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil {
+				d.printVerbosef("Ignoring the optional dependency on %s of item %s as it was not found", dependency, item.Name)
+				continue
+			}
+
+			addEdge(dependencyItem)
+		}
+
+		for _, dependencyItem := range orderedAfter[item] {
+			addEdge(dependencyItem)
+		}
+	}
+
+	return &graph, nil
+}
+
+// newRelationsGraph resolves every dependency that points to an existing item,
+// optional or not, including the implicit dependency of a child on its parents
+// and the After and Before hints. Unlike newDependencyGraph it never fails,
+// missing, self and repeated dependencies are left out.
+func (d *DependencyTreeService[T]) newRelationsGraph() *dependencyGraph[T] {
+	graph := dependencyGraph[T]{
+		items:        make([]*DependencyTreeItem[T], len(d.flatTree)),
+		positions:    make(map[*DependencyTreeItem[T]]int, len(d.flatTree)),
+		dependencies: make([][]int, len(d.flatTree)),
+		dependents:   make([][]int, len(d.flatTree)),
+	}
+
+	copy(graph.items, d.flatTree)
+	for idx, item := range d.flatTree {
+		graph.positions[item] = idx
+	}
+
+	orderedAfter := d.orderedAfter()
+	for idx, item := range d.flatTree {
+		seen := make(map[*DependencyTreeItem[T]]bool)
+		addEdge := func(dependencyItem *DependencyTreeItem[T]) {
+			seen[dependencyItem] = true
+			dependencyIndex := graph.positions[dependencyItem]
+			graph.dependencies[idx] = append(graph.dependencies[idx], dependencyIndex)
+			graph.dependents[dependencyIndex] = append(graph.dependents[dependencyIndex], idx)
+		}
+
+		for _, parent := range d.itemParents(item) {
+			addEdge(parent)
+		}
+
+		for _, dependency := range item.allDependencies() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil || dependencyItem == item || seen[dependencyItem] {
+				continue
+			}
+
+			addEdge(dependencyItem)
+		}
+
+		for _, dependencyItem := range orderedAfter[item] {
+			if dependencyItem != item && !seen[dependencyItem] {
+				addEdge(dependencyItem)
+			}
+		}
+	}
+
+	return &graph
+}
+
+// orderedAfter returns the items every item has to come after because of the
+// After and Before hints, hints on items that do not exist are left out.
+func (d *DependencyTreeService[T]) orderedAfter() map[*DependencyTreeItem[T]][]*DependencyTreeItem[T] {
+	result := make(map[*DependencyTreeItem[T]][]*DependencyTreeItem[T])
+	for _, item := range d.flatTree {
+		for _, other := range item.OrderedAfter() {
+			if otherItem := d.getItem(other); otherItem != nil {
+				result[item] = append(result[item], otherItem)
+			}
+		}
+
+		for _, other := range item.OrderedBefore() {
+			if otherItem := d.getItem(other); otherItem != nil {
+				result[otherItem] = append(result[otherItem], item)
+			}
+		}
+	}
+
+	return result
+}
+
+// unordered returns the smallest range of items that holds every item placed
+// before one of its dependencies, first is greater than last when every item is
+// in order. Every cycle is inside the range, as one of its items always comes
+// before the item it depends on.
+func (g *dependencyGraph[T]) unordered() (int, int) {
+	first, last := len(g.items), -1
+	for idx := range g.items {
+		for _, dependency := range g.dependencies[idx] {
+			if dependency >= idx {
+				first = min(first, idx)
+				last = max(last, dependency)
+			}
+		}
+	}
+
+	return first, last
+}
+
+// reversed returns the same graph with every edge pointing the other way, so an
+// item depends on the items that required it.
+func (g *dependencyGraph[T]) reversed() *dependencyGraph[T] {
+	return &dependencyGraph[T]{
+		items:        g.items,
+		positions:    g.positions,
+		dependencies: g.dependents,
+		dependents:   g.dependencies,
+	}
+}
+
+// placed runs Kahn's algorithm and reports which items could be ordered, the
+// items left out are part of, or depend on, a cycle.
+func (g *dependencyGraph[T]) placed() []bool {
+	result := make([]bool, len(g.items))
+	inDegree := make([]int, len(g.items))
+	queue := []int{}
+	for idx := range g.items {
+		inDegree[idx] = len(g.dependencies[idx])
+		if inDegree[idx] == 0 {
+			queue = append(queue, idx)
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		result[node] = true
+
+		for _, dependent := range g.dependents[node] {
+			inDegree[dependent] -= 1
+			if inDegree[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return result
+}
+
+// cycleError builds a CycleError with one cycle for every strongly connected
+// component left in the items that could not be placed.
+func (g *dependencyGraph[T]) cycleError(placed []bool) *CycleError {
+	result := CycleError{
+		Cycles: [][]CycleItem{},
+	}
+
+	for _, component := range g.stronglyConnectedComponents(placed) {
+		cycle := g.shortestCycle(component)
+		if len(cycle) == 0 {
+			continue
+		}
+
+		path := make([]CycleItem, 0, len(cycle))
+		for _, idx := range cycle {
+			path = append(path, CycleItem{
+				ID:   g.items[idx].ID,
+				Name: g.items[idx].Name,
+			})
+		}
+		result.Cycles = append(result.Cycles, path)
+	}
+
+	return &result
+}
+
+// stronglyConnectedComponents runs Tarjan's algorithm on the items that are not
+// placed and returns the components sorted by their first item.
+func (g *dependencyGraph[T]) stronglyConnectedComponents(placed []bool) [][]int {
+	index := 0
+	indexes := make([]int, len(g.items))
+	lowLinks := make([]int, len(g.items))
+	onStack := make([]bool, len(g.items))
+	stack := []int{}
+	components := [][]int{}
+
+	for idx := range indexes {
+		indexes[idx] = -1
+	}
+
+	var connect func(node int)
+	connect = func(node int) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index += 1
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, dependency := range g.dependencies[node] {
+			if placed[dependency] {
+				continue
+			}
+
+			if indexes[dependency] == -1 {
+				connect(dependency)
+				lowLinks[node] = min(lowLinks[node], lowLinks[dependency])
+			} else if onStack[dependency] {
+				lowLinks[node] = min(lowLinks[node], indexes[dependency])
+			}
+		}
+
+		if lowLinks[node] != indexes[node] {
+			return
+		}
+
+		component := []int{}
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		sort.Ints(component)
+		components = append(components, component)
+	}
+
+	for idx := range g.items {
+		if !placed[idx] && indexes[idx] == -1 {
+			connect(idx)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+
+	return components
+}
+
+// shortestCycle returns the shortest path from the first item of the component
+// back to itself, or nil if the component has no cycle.
+func (g *dependencyGraph[T]) shortestCycle(component []int) []int {
+	start := component[0]
+	members := make(map[int]bool, len(component))
+	for _, idx := range component {
+		members[idx] = true
+	}
+
+	previous := map[int]int{}
+	queue := []int{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, dependency := range g.dependencies[node] {
+			if !members[dependency] {
+				continue
+			}
+
+			if dependency == start {
+				cycle := []int{start}
+				for current := node; current != start; current = previous[current] {
+					cycle = append(cycle, current)
+				}
+				// the walk above goes backwards, so we reverse the middle of the path
+				for i, j := 1, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return append(cycle, start)
+			}
+
+			if _, visited := previous[dependency]; !visited {
+				previous[dependency] = node
+				queue = append(queue, dependency)
+			}
+		}
+	}
+
+	return nil
+}
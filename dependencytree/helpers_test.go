@@ -1,7 +1,6 @@
 package dependencytree
 
 import (
-	"strings"
 	"testing"
 
 	log "github.com/cjlapao/common-go-logger"
@@ -48,8 +47,7 @@ func TestPrintSimpleTree(t *testing.T) {
 	_, err := dpService.Build()
 	assert.NoError(t, err)
 
-	lines := dpService.printTree(dpService.tree, 0, "", true)
-	assert.Equal(t, expectedSimpleString, strings.Join(lines, "\n"))
+	assert.Equal(t, expectedSimpleString, dpService.String())
 }
 
 func TestPrintSimpleTreeWithChildren(t *testing.T) {
@@ -71,8 +69,7 @@ func TestPrintSimpleTreeWithChildren(t *testing.T) {
 	_, err := dpService.Build()
 	assert.NoError(t, err)
 
-	lines := dpService.printTree(dpService.tree, 0, "", true)
-	assert.Equal(t, expectedSimpleTreeWithChildrenString, strings.Join(lines, "\n"))
+	assert.Equal(t, expectedSimpleTreeWithChildrenString, dpService.String())
 }
 
 func TestPrintComplexTree(t *testing.T) {
@@ -108,20 +105,5 @@ func TestPrintComplexTree(t *testing.T) {
 	_, err := dpService.Build()
 	assert.NoError(t, err)
 
-	lines := dpService.printTree(dpService.tree, 0, "", false)
-	assert.Equal(t, expectedComplexString, strings.Join(lines, "\n"))
-}
-
-func TestShiftToWithInvalidIndex(t *testing.T) {
-	logger := log.Get()
-	logger.LogLevel = log.Debug
-	globalDependencyTreeService = nil
-	dpService := Get(MockObject1{})
-	dpService.SetLogger(logger)
-
-	_, _ = dpService.AddRootItem("item_6", "item 6", MockObject1{id: "item_6", someStoredValue: "item 6"})
-
-	_, err := dpService.shiftTo(0, 4)
-
-	assert.Error(t, err)
+	assert.Equal(t, expectedComplexString, dpService.String())
 }
@@ -0,0 +1,296 @@
+package dependencytree
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// TreeSchemaVersion is the version of the document written by MarshalJSON, it
+// is increased every time the document changes in a way older versions of this
+// package cannot read.
+const TreeSchemaVersion = 1
+
+// TreeDocument is the document written by MarshalJSON and read by LoadJSON.
+//
+//	{
+//	  "version": 1,
+//	  "items": [
+//	    {
+//	      "id": "api",
+//	      "name": "API",
+//	      "parent": "gateway",
+//	      "additionalParents": ["admin"],
+//	      "dependsOn": ["database"],
+//	      "optionalDependsOn": ["cache"],
+//	      "after": ["logging"],
+//	      "before": ["metrics"],
+//	      "metadata": {"timeout": "5s"},
+//	      "value": {}
+//	    }
+//	  ],
+//	  "edges": [
+//	    {"from": "api", "to": "database", "kind": "depends-on", "weight": 2, "metadata": {"reason": "stores sessions"}}
+//	  ]
+//	}
+//
+// The parent and the dependencies can hold the id or the name of another item,
+// an item without a parent is a root item. Optional dependencies and the after
+// and before hints are ignored when the item does not exist. The value is
+// written and read by the Codec of the service. The edges only hold the weight
+// and metadata of edges the items declare.
+type TreeDocument struct {
+	Version int            `json:"version"`
+	Items   []ItemDocument `json:"items"`
+	Edges   Edges          `json:"edges,omitempty"`
+}
+
+type ItemDocument struct {
+	ID                string                 `json:"id"`
+	Name              string                 `json:"name"`
+	Parent            string                 `json:"parent,omitempty"`
+	AdditionalParents []string               `json:"additionalParents,omitempty"`
+	DependsOn         []string               `json:"dependsOn,omitempty"`
+	OptionalDependsOn []string               `json:"optionalDependsOn,omitempty"`
+	After             []string               `json:"after,omitempty"`
+	Before            []string               `json:"before,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty"`
+	Value             json.RawMessage        `json:"value,omitempty"`
+}
+
+// Codec encodes and decodes the values of the items when a tree is saved or
+// loaded.
+type Codec[T interface{}] interface {
+	Encode(value T) (json.RawMessage, error)
+	Decode(data json.RawMessage) (T, error)
+}
+
+// JSONCodec is the default Codec, it uses encoding/json for the values.
+type JSONCodec[T interface{}] struct{}
+
+func (c JSONCodec[T]) Encode(value T) (json.RawMessage, error) {
+	return json.Marshal(value)
+}
+
+func (c JSONCodec[T]) Decode(data json.RawMessage) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+func (d *DependencyTreeService[T]) Codec() Codec[T] {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getCodec()
+}
+
+// SetCodec changes how the values of the items are saved and loaded, a nil
+// codec restores the JSONCodec.
+func (d *DependencyTreeService[T]) SetCodec(codec Codec[T]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.codec = codec
+}
+
+func (d *DependencyTreeService[T]) getCodec() Codec[T] {
+	if d.codec == nil {
+		return JSONCodec[T]{}
+	}
+
+	return d.codec
+}
+
+// MarshalJSON writes the items of the service as a TreeDocument, in the order
+// of the flat tree. The dependency on the parent that Build adds is left out.
+// Metadata is written with encoding/json, so a time.Duration is read back as a
+// number, use duration strings for metadata that needs to be loaded.
+func (d *DependencyTreeService[T]) MarshalJSON() ([]byte, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	codec := d.getCodec()
+	document := TreeDocument{
+		Version: TreeSchemaVersion,
+		Items:   make([]ItemDocument, 0, len(d.flatTree)),
+	}
+
+	for _, item := range d.flatTree {
+		value, err := codec.Encode(item.Value())
+		if err != nil {
+			return nil, fmt.Errorf("error encoding the value of item %s: %w", item.ID, err)
+		}
+
+		itemDocument := ItemDocument{
+			ID:                item.ID,
+			Name:              item.Name,
+			AdditionalParents: item.AdditionalParents(),
+			DependsOn:         []string{},
+			OptionalDependsOn: item.OptionalDependencies(),
+			After:             item.OrderedAfter(),
+			Before:            item.OrderedBefore(),
+			Metadata:          item.Metadata,
+			Value:             value,
+		}
+
+		// the parent id is written whether the tree was built or not
+		if item.Parent != nil {
+			itemDocument.Parent = item.Parent.ID
+		} else if item.parentName != "root" {
+			itemDocument.Parent = item.parentName
+		}
+
+		parents := d.itemParents(item)
+		for _, dependency := range item.IsDependentOn() {
+			if slices.Contains(parents, d.getItem(dependency)) {
+				continue
+			}
+			itemDocument.DependsOn = append(itemDocument.DependsOn, dependency)
+		}
+
+		document.Items = append(document.Items, itemDocument)
+	}
+
+	for _, edge := range d.allEdges() {
+		if edge.isAnnotated() {
+			document.Edges = append(document.Edges, edge)
+		}
+	}
+
+	return json.Marshal(document)
+}
+
+// LoadJSON replaces the items of the service with the ones in a TreeDocument,
+// the values are decoded with the Codec of the service. Nothing is changed if
+// the document cannot be loaded.
+func (d *DependencyTreeService[T]) LoadJSON(data []byte) error {
+	document := TreeDocument{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("error reading the tree document: %w", err)
+	}
+
+	if err := checkSchemaVersion(document.Version); err != nil {
+		return err
+	}
+
+	codec := d.Codec()
+	items := make([]*DependencyTreeItem[T], 0, len(document.Items))
+	for idx, itemDocument := range document.Items {
+		var value T
+		if len(itemDocument.Value) > 0 && string(itemDocument.Value) != "null" {
+			decoded, err := codec.Decode(itemDocument.Value)
+			if err != nil {
+				return fmt.Errorf("error decoding the value of item %s: %w", itemDocument.ID, err)
+			}
+			value = decoded
+		}
+
+		item, err := newItemFromDocument(idx, itemDocument, value)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, item)
+	}
+
+	_, err := d.replaceItems(items, document.Edges)
+	return err
+}
+
+func checkSchemaVersion(version int) error {
+	if version != TreeSchemaVersion {
+		return fmt.Errorf("unsupported tree schema version %d, expected %d", version, TreeSchemaVersion)
+	}
+
+	return nil
+}
+
+// newItemFromDocument creates the item described by the document, the value
+// is decoded by the caller.
+func newItemFromDocument[T interface{}](idx int, itemDocument ItemDocument, value T) (*DependencyTreeItem[T], error) {
+	item, err := NewDependencyTreeItem[T](itemDocument.ID, itemDocument.Name, value)
+	if err != nil {
+		return nil, fmt.Errorf("item %d is not valid: %w", idx, err)
+	}
+
+	if itemDocument.Parent != "" {
+		item.SetParent(itemDocument.Parent)
+	}
+
+	for _, parent := range itemDocument.AdditionalParents {
+		if err := item.AddParent(parent); err != nil {
+			return nil, fmt.Errorf("item %s has the parent %s more than once", item.ID, parent)
+		}
+	}
+
+	for _, dependency := range itemDocument.DependsOn {
+		if err := item.DependsOn(dependency); err != nil {
+			return nil, fmt.Errorf("item %s depends on %s more than once", item.ID, dependency)
+		}
+	}
+
+	for _, dependency := range itemDocument.OptionalDependsOn {
+		if err := item.DependsOnOptional(dependency); err != nil {
+			return nil, fmt.Errorf("item %s depends on %s more than once", item.ID, dependency)
+		}
+	}
+
+	for _, other := range itemDocument.After {
+		if err := item.After(other); err != nil {
+			return nil, fmt.Errorf("item %s is ordered after %s more than once", item.ID, other)
+		}
+	}
+
+	for _, other := range itemDocument.Before {
+		if err := item.Before(other); err != nil {
+			return nil, fmt.Errorf("item %s is ordered before %s more than once", item.ID, other)
+		}
+	}
+
+	for key, value := range itemDocument.Metadata {
+		_ = item.SetProperty(key, value)
+	}
+
+	return item, nil
+}
+
+// replaceItems swaps the items of the service for the loaded ones. When two
+// items share an id or a name it returns the index of the second one and leaves
+// the service untouched, errors in the edges are returned with a -1 index.
+func (d *DependencyTreeService[T]) replaceItems(items []*DependencyTreeItem[T], edges Edges) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ids := make(map[string]bool, len(items))
+	names := make(map[string]bool, len(items))
+	for idx, item := range items {
+		if ids[d.key(item.ID)] || names[d.key(item.Name)] {
+			return idx, fmt.Errorf("item with id %v already exists", item.ID)
+		}
+		ids[d.key(item.ID)] = true
+		names[d.key(item.Name)] = true
+	}
+
+	annotations, err := d.edgeAnnotations(items, edges)
+	if err != nil {
+		return -1, err
+	}
+
+	d.edges = annotations
+	d.flatTree = items
+	d.tree = []*DependencyTreeItem[T]{}
+	d.sorted = false
+	d.reindex()
+	d.changed()
+
+	for _, item := range d.flatTree {
+		for _, dependency := range item.IsDependentOn() {
+			if dependencyItem := d.getItem(dependency); dependencyItem != nil {
+				dependencyItem.AddRequiredBy(item.ID)
+			}
+		}
+	}
+
+	return -1, nil
+}
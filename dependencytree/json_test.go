@@ -0,0 +1,256 @@
+package dependencytree
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockObject1Codec struct{}
+
+type mockObject1Document struct {
+	ID          string `json:"id"`
+	StoredValue string `json:"storedValue"`
+}
+
+func (c mockObject1Codec) Encode(value MockObject1) (json.RawMessage, error) {
+	return json.Marshal(mockObject1Document{ID: value.id, StoredValue: value.someStoredValue})
+}
+
+func (c mockObject1Codec) Decode(data json.RawMessage) (MockObject1, error) {
+	document := mockObject1Document{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return MockObject1{}, err
+	}
+
+	return MockObject1{id: document.ID, someStoredValue: document.StoredValue}, nil
+}
+
+type failingCodec struct{}
+
+func (c failingCodec) Encode(value MockObject1) (json.RawMessage, error) {
+	return nil, errors.New("encode failed")
+}
+
+func (c failingCodec) Decode(data json.RawMessage) (MockObject1, error) {
+	return MockObject1{}, errors.New("decode failed")
+}
+
+func TestMarshalJSON(t *testing.T) {
+	t.Run("Writes the versioned document", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database", someStoredValue: "postgres"})
+		api, _ := service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		_ = api.SetProperty("timeout", "5s")
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		data, err := json.Marshal(service)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"version": 1,
+			"items": [
+				{"id": "database", "name": "Database", "value": {"id": "database", "storedValue": "postgres"}},
+				{"id": "api", "name": "API", "dependsOn": ["database"], "metadata": {"timeout": "5s"}, "value": {"id": "api", "storedValue": ""}},
+				{"id": "handlers", "name": "Handlers", "parent": "api", "value": {"id": "handlers", "storedValue": ""}}
+			]
+		}`, string(data))
+	})
+
+	t.Run("Writes the same document before and after a build", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		before, err := service.MarshalJSON()
+		require.NoError(t, err)
+		_, err = service.Build()
+		require.NoError(t, err)
+
+		after, err := service.MarshalJSON()
+
+		require.NoError(t, err)
+		assert.JSONEq(t, string(before), string(after))
+	})
+
+	t.Run("Returns the codec error", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(failingCodec{})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+
+		_, err := service.MarshalJSON()
+
+		assert.EqualError(t, err, "error encoding the value of item database: encode failed")
+	})
+
+	t.Run("Uses encoding/json by default", func(t *testing.T) {
+		service := New[map[string]string]()
+		_, _ = service.AddRootItem("database", "Database", map[string]string{"driver": "postgres"})
+
+		data, err := service.MarshalJSON()
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version": 1, "items": [{"id": "database", "name": "Database", "value": {"driver": "postgres"}}]}`, string(data))
+		assert.IsType(t, JSONCodec[map[string]string]{}, service.Codec())
+	})
+}
+
+func TestLoadJSON(t *testing.T) {
+	t.Run("Round trips a tree", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database", someStoredValue: "postgres"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_ = service.DependsOn("api", "database")
+		data, err := service.MarshalJSON()
+		require.NoError(t, err)
+
+		loaded := New[MockObject1]()
+		loaded.SetCodec(mockObject1Codec{})
+		err = loaded.LoadJSON(data)
+		require.NoError(t, err)
+
+		items, err := loaded.Build()
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, "database", items[0].ID)
+		assert.Equal(t, "api", items[1].ID)
+		assert.Equal(t, "handlers", items[2].ID)
+		assert.Equal(t, "postgres", items[0].Value().someStoredValue)
+		assert.Equal(t, []string{"api"}, items[0].RequiredBy())
+		assert.Equal(t, "api", items[2].GetParentId())
+	})
+
+	t.Run("Round trips optional dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.DependsOnOptional("api", "cache")
+		_ = service.DependsOnOptional("api", "missing")
+		data, err := service.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"optionalDependsOn":["cache","missing"]`)
+
+		loaded := New[MockObject1]()
+		loaded.SetCodec(mockObject1Codec{})
+		require.NoError(t, loaded.LoadJSON(data))
+
+		assert.Equal(t, []string{"cache", "missing"}, loaded.GetItem("api").OptionalDependencies())
+		assert.Empty(t, loaded.GetItem("cache").RequiredBy())
+	})
+
+	t.Run("Round trips ordering hints", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("logging", "Logging", MockObject1{id: "logging"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.After("api", "logging")
+		_ = service.Before("api", "metrics")
+		service.SetCodec(mockObject1Codec{})
+		data, err := service.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"after":["logging"],"before":["metrics"]`)
+
+		loaded := New[MockObject1]()
+		loaded.SetCodec(mockObject1Codec{})
+		require.NoError(t, loaded.LoadJSON(data))
+
+		assert.Equal(t, []string{"logging"}, loaded.GetItem("api").OrderedAfter())
+		assert.Equal(t, []string{"metrics"}, loaded.GetItem("api").OrderedBefore())
+	})
+
+	t.Run("Round trips additional parents", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("worker", "Worker", MockObject1{id: "worker"})
+		_, _ = service.AddItem("cache", "Cache", "api", MockObject1{id: "cache"})
+		_ = service.AddParent("cache", "worker")
+		_, err := service.Build()
+		require.NoError(t, err)
+		data, err := service.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `{"id":"cache","name":"Cache","parent":"api","additionalParents":["worker"],"value"`)
+
+		loaded := New[MockObject1]()
+		loaded.SetCodec(mockObject1Codec{})
+		require.NoError(t, loaded.LoadJSON(data))
+		_, err = loaded.Build()
+		require.NoError(t, err)
+
+		assert.True(t, loaded.GetItem("cache").IsShared())
+	})
+
+	t.Run("Round trips edge annotations", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.DependsOn("api", "database")
+		_ = service.AddEdge(Edge{From: "api", To: "database", Kind: ExplicitEdge, Weight: 2, Metadata: map[string]interface{}{"reason": "stores sessions"}})
+		data, err := service.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"edges":[{"from":"api","to":"database","kind":"depends-on","weight":2,"metadata":{"reason":"stores sessions"}}]`)
+
+		loaded := New[MockObject1]()
+		loaded.SetCodec(mockObject1Codec{})
+		require.NoError(t, loaded.LoadJSON(data))
+
+		assert.Equal(t, Edges{{From: "api", To: "database", Kind: ExplicitEdge, Weight: 2, Metadata: map[string]interface{}{"reason": "stores sessions"}}}, loaded.Edges())
+	})
+
+	t.Run("Replaces the current items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("old", "old", MockObject1{id: "old"})
+
+		err := service.LoadJSON([]byte(`{"version": 1, "items": [{"id": "new", "name": "new"}]}`))
+
+		require.NoError(t, err)
+		assert.Nil(t, service.GetItem("old"))
+		require.NotNil(t, service.GetItem("new"))
+		assert.Equal(t, "root", service.GetItem("new").GetParentName())
+	})
+
+	t.Run("Returns errors without changing the service", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			document string
+			err      string
+		}{
+			{"invalid json", `{"version": 1,`, "error reading the tree document: unexpected end of JSON input"},
+			{"missing version", `{"items": []}`, "unsupported tree schema version 0, expected 1"},
+			{"newer version", `{"version": 2, "items": []}`, "unsupported tree schema version 2, expected 1"},
+			{"missing id", `{"version": 1, "items": [{"name": "a"}]}`, "item 0 is not valid: id must not be empty"},
+			{"duplicated id", `{"version": 1, "items": [{"id": "a", "name": "a"}, {"id": "a", "name": "b"}]}`, "item with id a already exists"},
+			{"duplicated dependency", `{"version": 1, "items": [{"id": "a", "name": "a", "dependsOn": ["b", "b"]}]}`, "item a depends on b more than once"},
+			{"duplicated parent", `{"version": 1, "items": [{"id": "a", "name": "a", "parent": "b", "additionalParents": ["b"]}]}`, "item a has the parent b more than once"},
+			{"duplicated after hint", `{"version": 1, "items": [{"id": "a", "name": "a", "after": ["b", "b"]}]}`, "item a is ordered after b more than once"},
+			{"duplicated optional dependency", `{"version": 1, "items": [{"id": "a", "name": "a", "dependsOn": ["b"], "optionalDependsOn": ["b"]}]}`, "item a depends on b more than once"},
+			{"undeclared edge", `{"version": 1, "items": [{"id": "a", "name": "a"}], "edges": [{"from": "a", "to": "b", "kind": "after"}]}`, "edge a -after-> b is not declared by item a"},
+			{"edge of missing item", `{"version": 1, "items": [], "edges": [{"from": "a", "to": "b", "kind": "after"}]}`, "edge a -after-> b: item a not found"},
+			{"edge of unknown kind", `{"version": 1, "items": [{"id": "a", "name": "a"}], "edges": [{"from": "a", "to": "b", "kind": "requires"}]}`, "edge a -requires-> b: unknown edge kind requires"},
+			{"invalid value", `{"version": 1, "items": [{"id": "a", "name": "a", "value": 1}]}`, "error decoding the value of item a: decode failed"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				service := New[MockObject1]()
+				service.SetCodec(failingCodec{})
+				_, _ = service.AddRootItem("existing", "existing", MockObject1{id: "existing"})
+
+				err := service.LoadJSON([]byte(test.document))
+
+				assert.EqualError(t, err, test.err)
+				assert.NotNil(t, service.GetItem("existing"))
+			})
+		}
+	})
+}
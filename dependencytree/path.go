@@ -0,0 +1,204 @@
+package dependencytree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PathEdgeKind tells why one item of a path comes after the next one, it uses
+// the same kinds as the edges of the service.
+type PathEdgeKind = EdgeKind
+
+// PathEdge is one step of a DependencyPath, From comes after To.
+type PathEdge[T interface{}] struct {
+	From *DependencyTreeItem[T]
+	To   *DependencyTreeItem[T]
+	Kind PathEdgeKind
+}
+
+// DependencyPath is a chain of edges from an item to one of the items it
+// depends on.
+type DependencyPath[T interface{}] []PathEdge[T]
+
+// String returns the path as "x -> parent p -> y", optional dependencies are
+// written as "optional y" and ordering hints as "after y".
+func (p DependencyPath[T]) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	parts := []string{p[0].From.ID}
+	for _, edge := range p {
+		switch edge.Kind {
+		case ParentEdge:
+			parts = append(parts, "parent "+edge.To.ID)
+		case OptionalEdge:
+			parts = append(parts, "optional "+edge.To.ID)
+		case AfterEdge, BeforeEdge:
+			parts = append(parts, "after "+edge.To.ID)
+		default:
+			parts = append(parts, edge.To.ID)
+		}
+	}
+
+	return strings.Join(parts, " -> ")
+}
+
+// Paths returns every path from an item to one of the items it depends on,
+// following the dependencies and the parents, with the shortest paths first.
+// The number of paths can grow quickly in dense graphs, use ShortestPath when
+// one is enough.
+func (d *DependencyTreeService[T]) Paths(from string, to string) ([]DependencyPath[T], error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	fromItem, toItem, err := d.pathItems(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []DependencyPath[T]{}
+	visited := map[*DependencyTreeItem[T]]bool{fromItem: true}
+	path := DependencyPath[T]{}
+
+	var walk func(current *DependencyTreeItem[T])
+	walk = func(current *DependencyTreeItem[T]) {
+		for _, edge := range d.pathEdges(current) {
+			if visited[edge.To] {
+				continue
+			}
+
+			path = append(path, edge)
+			if edge.To == toItem {
+				result = append(result, append(DependencyPath[T]{}, path...))
+			} else {
+				visited[edge.To] = true
+				walk(edge.To)
+				visited[edge.To] = false
+			}
+			path = path[:len(path)-1]
+		}
+	}
+	if fromItem != toItem {
+		walk(fromItem)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i]) < len(result[j])
+	})
+
+	return result, nil
+}
+
+// ShortestPath returns one of the shortest paths from an item to one of the
+// items it depends on, or nil if it does not depend on it.
+func (d *DependencyTreeService[T]) ShortestPath(from string, to string) (DependencyPath[T], error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	fromItem, toItem, err := d.pathItems(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := map[*DependencyTreeItem[T]]PathEdge[T]{}
+	visited := map[*DependencyTreeItem[T]]bool{fromItem: true}
+	queue := []*DependencyTreeItem[T]{fromItem}
+	for len(queue) > 0 && fromItem != toItem {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range d.pathEdges(current) {
+			if visited[edge.To] {
+				continue
+			}
+
+			visited[edge.To] = true
+			previous[edge.To] = edge
+			queue = append(queue, edge.To)
+		}
+	}
+
+	if _, found := previous[toItem]; !found {
+		return nil, nil
+	}
+
+	path := DependencyPath[T]{}
+	for current := toItem; current != fromItem; current = previous[current].From {
+		path = append(path, previous[current])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, nil
+}
+
+func (d *DependencyTreeService[T]) pathItems(from string, to string) (*DependencyTreeItem[T], *DependencyTreeItem[T], error) {
+	fromItem := d.getItem(from)
+	if fromItem == nil {
+		return nil, nil, fmt.Errorf("item with id %v not found", from)
+	}
+
+	toItem := d.getItem(to)
+	if toItem == nil {
+		return nil, nil, fmt.Errorf("item with id %v not found", to)
+	}
+
+	return fromItem, toItem, nil
+}
+
+// pathEdges returns the edges leaving the item, the parents first, then the
+// dependencies in the order they were added, the optional ones and last the
+// ordering hints. Build adds the parents as dependencies, so a dependency on a
+// parent is always reported as a parent edge.
+func (d *DependencyTreeService[T]) pathEdges(item *DependencyTreeItem[T]) []PathEdge[T] {
+	result := []PathEdge[T]{}
+	seen := map[*DependencyTreeItem[T]]bool{item: true}
+
+	for _, parent := range d.itemParents(item) {
+		if !seen[parent] {
+			seen[parent] = true
+			result = append(result, PathEdge[T]{From: item, To: parent, Kind: ParentEdge})
+		}
+	}
+
+	for idx, dependency := range item.allDependencies() {
+		dependencyItem := d.getItem(dependency)
+		if dependencyItem == nil || seen[dependencyItem] {
+			continue
+		}
+
+		kind := ExplicitEdge
+		if idx >= len(item.IsDependentOn()) {
+			kind = OptionalEdge
+		}
+
+		seen[dependencyItem] = true
+		result = append(result, PathEdge[T]{From: item, To: dependencyItem, Kind: kind})
+	}
+
+	for _, other := range item.OrderedAfter() {
+		if otherItem := d.getItem(other); otherItem != nil && !seen[otherItem] {
+			seen[otherItem] = true
+			result = append(result, PathEdge[T]{From: item, To: otherItem, Kind: AfterEdge})
+		}
+	}
+
+	for _, otherItem := range d.flatTree {
+		if seen[otherItem] {
+			continue
+		}
+
+		for _, other := range otherItem.OrderedBefore() {
+			if d.getItem(other) == item {
+				seen[otherItem] = true
+				result = append(result, PathEdge[T]{From: item, To: otherItem, Kind: BeforeEdge})
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,213 @@
+package dependencytree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathStrings(paths []DependencyPath[MockObject1]) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, path.String())
+	}
+
+	return result
+}
+
+func TestPaths(t *testing.T) {
+	t.Run("Returns every path with the shortest first", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_ = service.DependsOn("gateway", "config")
+
+		paths, err := service.Paths("gateway", "config")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"gateway -> config",
+			"gateway -> handlers -> parent api -> cache -> config",
+			"gateway -> handlers -> parent api -> database -> config",
+		}, pathStrings(paths))
+	})
+
+	t.Run("Labels the edges", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		paths, err := service.Paths("handlers", "database")
+
+		require.NoError(t, err)
+		require.Len(t, paths, 1)
+		require.Len(t, paths[0], 2)
+		assert.Equal(t, "handlers", paths[0][0].From.ID)
+		assert.Equal(t, "api", paths[0][0].To.ID)
+		assert.Equal(t, ParentEdge, paths[0][0].Kind)
+		assert.Equal(t, "api", paths[0][1].From.ID)
+		assert.Equal(t, "database", paths[0][1].To.ID)
+		assert.Equal(t, ExplicitEdge, paths[0][1].Kind)
+	})
+
+	t.Run("Follows optional dependencies and ordering hints", func(t *testing.T) {
+		service := New[MockObject1]()
+		for _, id := range []string{"metrics", "api", "cache", "logging"} {
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		_ = service.After("metrics", "api")
+		_ = service.DependsOnOptional("api", "cache")
+		_ = service.Before("logging", "cache")
+
+		paths, err := service.Paths("metrics", "logging")
+
+		require.NoError(t, err)
+		require.Len(t, paths, 1)
+		assert.Equal(t, []PathEdgeKind{AfterEdge, OptionalEdge, BeforeEdge}, []PathEdgeKind{paths[0][0].Kind, paths[0][1].Kind, paths[0][2].Kind})
+		assert.Equal(t, "metrics -> after api -> optional cache -> after logging", paths[0].String())
+	})
+
+	t.Run("Returns no paths when the item does not depend on the other", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		paths, err := service.Paths("config", "gateway")
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+
+		paths, err = service.Paths("config", "config")
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+	})
+
+	t.Run("Does not loop on cycles", func(t *testing.T) {
+		service := New[MockObject1]()
+		for _, id := range []string{"a", "b", "c"} {
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "a")
+		_ = service.DependsOn("b", "c")
+
+		paths, err := service.Paths("a", "c")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a -> b -> c"}, pathStrings(paths))
+	})
+
+	t.Run("Returns an error for missing items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		_, err := service.Paths("missing", "config")
+		assert.EqualError(t, err, "item with id missing not found")
+
+		_, err = service.Paths("config", "missing")
+		assert.EqualError(t, err, "item with id missing not found")
+	})
+}
+
+func TestShortestPath(t *testing.T) {
+	t.Run("Returns the shortest path", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		path, err := service.ShortestPath("gateway", "config")
+
+		require.NoError(t, err)
+		assert.Equal(t, "gateway -> handlers -> parent api -> cache -> config", path.String())
+	})
+
+	t.Run("Returns nil when the item does not depend on the other", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		path, err := service.ShortestPath("config", "gateway")
+		require.NoError(t, err)
+		assert.Nil(t, path)
+
+		path, err = service.ShortestPath("config", "config")
+		require.NoError(t, err)
+		assert.Nil(t, path)
+		assert.Empty(t, path.String())
+	})
+
+	t.Run("Returns an error for missing items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_, err := service.ShortestPath("gateway", "missing")
+
+		assert.EqualError(t, err, "item with id missing not found")
+	})
+}
@@ -0,0 +1,119 @@
+package dependencytree
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// TransitiveDependencies returns every item the item needs, following the
+// dependencies and the parents, in the order they need to be started. A
+// maxDepth of one only returns the direct dependencies and the parent, zero or
+// less has no limit.
+func (d *DependencyTreeService[T]) TransitiveDependencies(nameOrId string, maxDepth int) ([]*DependencyTreeItem[T], error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	item := d.getItem(nameOrId)
+	if item == nil {
+		return nil, fmt.Errorf("item with id %v not found", nameOrId)
+	}
+
+	graph := d.newRelationsGraph()
+	return graph.topologicalOrder(graph.reachable(graph.positions[item], graph.dependencies, maxDepth))
+}
+
+// TransitiveDependents returns every item that needs the item, following the
+// dependencies and the children, in the order they need to be started. A
+// maxDepth of one only returns the direct dependents and the children, zero or
+// less has no limit.
+func (d *DependencyTreeService[T]) TransitiveDependents(nameOrId string, maxDepth int) ([]*DependencyTreeItem[T], error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	item := d.getItem(nameOrId)
+	if item == nil {
+		return nil, fmt.Errorf("item with id %v not found", nameOrId)
+	}
+
+	graph := d.newRelationsGraph()
+	return graph.topologicalOrder(graph.reachable(graph.positions[item], graph.dependents, maxDepth))
+}
+
+// reachable walks the edges breadth first from the start item and returns the
+// items found within maxDepth steps, without the start item.
+func (g *dependencyGraph[T]) reachable(start int, edges [][]int, maxDepth int) []int {
+	result := []int{}
+	visited := map[int]bool{start: true}
+	queue := []int{start}
+
+	for depth := 1; len(queue) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		next := []int{}
+		for _, node := range queue {
+			for _, edge := range edges[node] {
+				if visited[edge] {
+					continue
+				}
+
+				visited[edge] = true
+				result = append(result, edge)
+				next = append(next, edge)
+			}
+		}
+		queue = next
+	}
+
+	return result
+}
+
+// topologicalOrder sorts the items so every item comes after the items it
+// depends on, items that do not depend on each other keep the order of the flat
+// tree. It returns a CycleError if the items depend on each other in a circle.
+func (g *dependencyGraph[T]) topologicalOrder(indexes []int) ([]*DependencyTreeItem[T], error) {
+	members := make(map[int]bool, len(indexes))
+	for _, idx := range indexes {
+		members[idx] = true
+	}
+
+	// without parent positions the queue hands out the lowest index first
+	inDegree := make(map[int]int, len(indexes))
+	ready := &readyQueue{}
+	for _, idx := range indexes {
+		for _, dependency := range g.dependencies[idx] {
+			if members[dependency] {
+				inDegree[idx] += 1
+			}
+		}
+		if inDegree[idx] == 0 {
+			heap.Push(ready, readyItem{index: idx})
+		}
+	}
+
+	result := make([]*DependencyTreeItem[T], 0, len(indexes))
+	for ready.Len() > 0 {
+		next, _ := heap.Pop(ready).(readyItem)
+		node := next.index
+		result = append(result, g.items[node])
+
+		for _, dependent := range g.dependents[node] {
+			if !members[dependent] {
+				continue
+			}
+
+			inDegree[dependent] -= 1
+			if inDegree[dependent] == 0 {
+				heap.Push(ready, readyItem{index: dependent})
+			}
+		}
+	}
+
+	if len(result) < len(indexes) {
+		// only the items left in the query can be part of the cycle
+		placed := make([]bool, len(g.items))
+		for idx := range placed {
+			placed[idx] = !members[idx] || inDegree[idx] == 0
+		}
+		return nil, g.cycleError(placed)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package dependencytree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func itemIDs(items []*DependencyTreeItem[MockObject1]) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.ID)
+	}
+
+	return result
+}
+
+func TestTransitiveDependencies(t *testing.T) {
+	t.Run("Follows dependencies and parents in topological order", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		items, err := service.TransitiveDependencies("gateway", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "cache", "api", "handlers"}, itemIDs(items))
+	})
+
+	t.Run("Limits the depth", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		items, err := service.TransitiveDependencies("gateway", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "handlers"}, itemIDs(items))
+
+		items, err = service.TransitiveDependencies("handlers", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api"}, itemIDs(items))
+	})
+
+	t.Run("Gives the same result after build", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		items, err := service.TransitiveDependencies("Gateway", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "database", "cache", "api", "handlers"}, itemIDs(items))
+	})
+
+	t.Run("Returns an empty list for root items without dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		items, err := service.TransitiveDependencies("config", 0)
+
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("Returns an error for missing items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_, err := service.TransitiveDependencies("missing", 0)
+
+		assert.EqualError(t, err, "item with id missing not found")
+	})
+
+	t.Run("Returns a cycle error", func(t *testing.T) {
+		service := New[MockObject1]()
+		for _, id := range []string{"a", "b", "c"} {
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "c")
+		_ = service.DependsOn("c", "b")
+
+		_, err := service.TransitiveDependencies("a", 0)
+
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, "circular dependency detected: b (b) -> c (c) -> b (b)", err.Error())
+	})
+}
+
+func TestTransitiveDependents(t *testing.T) {
+	t.Run("Follows dependents and children in topological order", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		items, err := service.TransitiveDependents("config", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database", "cache", "api", "handlers", "gateway"}, itemIDs(items))
+	})
+
+	t.Run("Limits the depth", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+
+		items, err := service.TransitiveDependents("config", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database", "cache"}, itemIDs(items))
+
+		items, err = service.TransitiveDependents("api", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"handlers"}, itemIDs(items))
+	})
+
+	t.Run("Returns an error for missing items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("config", "Config", MockObject1{id: "config"})
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("gateway", "Gateway", MockObject1{id: "gateway"})
+		_ = service.DependsOn("database", "config")
+		_ = service.DependsOn("cache", "config")
+		_ = service.DependsOn("api", "cache")
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("gateway", "handlers")
+		_, err := service.TransitiveDependents("missing", 0)
+
+		assert.EqualError(t, err, "item with id missing not found")
+	})
+}
@@ -0,0 +1,168 @@
+package dependencytree
+
+import (
+	"slices"
+)
+
+// redundantDependency is a dependency declared with DependsOn that is already
+// implied by another path, Path goes from the item to the dependency.
+type redundantDependency[T interface{}] struct {
+	Item       *DependencyTreeItem[T]
+	Dependency string
+	Path       []*DependencyTreeItem[T]
+}
+
+// TransitiveReduction returns the edges of the items without the dependencies
+// added with DependsOn that are already implied by another path through the
+// parents and the dependencies, the order the items are built in and the items
+// skipped when one fails stay the same. The service is not changed, use
+// ApplyTransitiveReduction to remove the redundant dependencies.
+func (d *DependencyTreeService[T]) TransitiveReduction() (Edges, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	redundant, err := d.checkedRedundantDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	result := Edges{}
+	for _, edge := range d.allEdges() {
+		if !slices.ContainsFunc(redundant, func(dependency redundantDependency[T]) bool {
+			return edge.Kind == ExplicitEdge && edge.From == dependency.Item.ID && edge.To == dependency.Dependency
+		}) {
+			result = append(result, edge)
+		}
+	}
+
+	return result, nil
+}
+
+// ApplyTransitiveReduction removes the dependencies added with DependsOn that
+// are already implied by another path and returns the removed edges. Build
+// needs to be called again for the tree to reflect the change.
+func (d *DependencyTreeService[T]) ApplyTransitiveReduction() (Edges, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	redundant, err := d.checkedRedundantDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	result := Edges{}
+	for _, dependency := range redundant {
+		item := dependency.Item
+		result = append(result, d.edge(item, dependency.Dependency, ExplicitEdge))
+		item.isDependentOn = slices.DeleteFunc(slices.Clone(item.isDependentOn), func(other string) bool {
+			return other == dependency.Dependency
+		})
+		delete(d.edges, d.edgeKey(item.ID, dependency.Dependency, ExplicitEdge))
+
+		// the item can still require the dependency by its name or id
+		dependencyItem := d.getItem(dependency.Dependency)
+		if !slices.ContainsFunc(item.IsDependentOn(), func(other string) bool {
+			return d.getItem(other) == dependencyItem
+		}) {
+			dependencyItem.requiredBy = slices.DeleteFunc(slices.Clone(dependencyItem.requiredBy), func(id string) bool {
+				return id == item.ID
+			})
+		}
+	}
+	if len(redundant) > 0 {
+		d.changed()
+	}
+
+	return result, nil
+}
+
+// checkedRedundantDependencies returns the redundant dependencies, or the
+// cycles that keep them from being found.
+func (d *DependencyTreeService[T]) checkedRedundantDependencies() ([]redundantDependency[T], error) {
+	graph := d.newRelationsGraph()
+	placed := graph.placed()
+	if slices.Contains(placed, false) {
+		return nil, graph.cycleError(placed)
+	}
+
+	return d.redundantDependencies(), nil
+}
+
+// redundantDependencies finds the dependencies added with DependsOn on items
+// the item already requires through one of its other parents or dependencies.
+// Only the edges that skip the item when they fail are followed, so removing
+// them does not change what Execute skips. The caller must make sure there are
+// no cycles.
+func (d *DependencyTreeService[T]) redundantDependencies() []redundantDependency[T] {
+	result := []redundantDependency[T]{}
+	for _, item := range d.flatTree {
+		parents := d.itemParents(item)
+		seen := make(map[*DependencyTreeItem[T]]bool)
+		for _, dependency := range item.IsDependentOn() {
+			dependencyItem := d.getItem(dependency)
+			if dependencyItem == nil || dependencyItem == item || seen[dependencyItem] || slices.Contains(parents, dependencyItem) {
+				continue
+			}
+			seen[dependencyItem] = true
+
+			if path := d.requiredPath(item, dependencyItem); path != nil {
+				result = append(result, redundantDependency[T]{
+					Item:       item,
+					Dependency: dependency,
+					Path:       path,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// requiredPath returns the shortest path from the item to the dependency that
+// does not use the direct edge between them, or nil if there is none.
+func (d *DependencyTreeService[T]) requiredPath(item *DependencyTreeItem[T], dependency *DependencyTreeItem[T]) []*DependencyTreeItem[T] {
+	previous := map[*DependencyTreeItem[T]]*DependencyTreeItem[T]{item: nil}
+	queue := []*DependencyTreeItem[T]{item}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range d.requiredItems(current) {
+			if _, visited := previous[next]; visited || (current == item && next == dependency) {
+				continue
+			}
+
+			previous[next] = current
+			if next == dependency {
+				path := []*DependencyTreeItem[T]{}
+				for step := next; step != nil; step = previous[step] {
+					path = append(path, step)
+				}
+				slices.Reverse(path)
+				return path
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	return nil
+}
+
+// requiredItems returns the parents of the item and the items it depends on
+// that exist, optional dependencies are left out as they do not skip the item.
+func (d *DependencyTreeService[T]) requiredItems(item *DependencyTreeItem[T]) []*DependencyTreeItem[T] {
+	result := []*DependencyTreeItem[T]{}
+	for _, parent := range d.itemParents(item) {
+		if parent != item {
+			result = append(result, parent)
+		}
+	}
+
+	for _, dependency := range item.IsDependentOn() {
+		if dependencyItem := d.getItem(dependency); dependencyItem != nil && dependencyItem != item && !slices.Contains(result, dependencyItem) {
+			result = append(result, dependencyItem)
+		}
+	}
+
+	return result
+}
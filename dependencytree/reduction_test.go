@@ -0,0 +1,127 @@
+package dependencytree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitiveReduction(t *testing.T) {
+	t.Run("Leaves out the redundant dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+
+		edges, err := service.TransitiveReduction()
+
+		require.NoError(t, err)
+		assert.Equal(t, Edges{
+			{From: "a", To: "b", Kind: ExplicitEdge},
+			{From: "b", To: "c", Kind: ExplicitEdge},
+		}, edges)
+		assert.Equal(t, []string{"b", "c"}, service.GetItem("a").IsDependentOn())
+	})
+
+	t.Run("Follows the parents but not the optional dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+		_, _ = service.AddRootItem("worker", "Worker", MockObject1{id: "worker"})
+		_ = service.DependsOn("api", "database")
+		_ = service.DependsOn("handlers", "database")
+		_ = service.DependsOn("cache", "database")
+		_ = service.DependsOnOptional("worker", "cache")
+		_ = service.DependsOn("worker", "database")
+
+		edges, err := service.TransitiveReduction()
+
+		require.NoError(t, err)
+		assert.Empty(t, edges.WithKind(ExplicitEdge).From("handlers"))
+		assert.Len(t, edges.WithKind(ExplicitEdge).From("worker"), 1)
+		assert.Len(t, edges.WithKind(ParentEdge, OptionalEdge), 2)
+	})
+
+	t.Run("Ordering hints do not make a dependency redundant", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.After("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+
+		edges, err := service.TransitiveReduction()
+
+		require.NoError(t, err)
+		assert.Len(t, edges, 3)
+	})
+
+	t.Run("Returns the cycles", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+		_ = service.DependsOn("c", "a")
+
+		_, err := service.TransitiveReduction()
+		var cycleErr *CycleError
+		assert.ErrorAs(t, err, &cycleErr)
+
+		_, err = service.ApplyTransitiveReduction()
+		assert.ErrorAs(t, err, &cycleErr)
+	})
+}
+
+func TestApplyTransitiveReduction(t *testing.T) {
+	t.Run("Removes the redundant dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+		require.NoError(t, service.AddEdge(Edge{From: "a", To: "c", Kind: ExplicitEdge, Weight: 2}))
+		before, err := service.Build()
+		require.NoError(t, err)
+
+		removed, err := service.ApplyTransitiveReduction()
+
+		require.NoError(t, err)
+		assert.Equal(t, Edges{{From: "a", To: "c", Kind: ExplicitEdge, Weight: 2}}, removed)
+		assert.Equal(t, []string{"b"}, service.GetItem("a").IsDependentOn())
+		assert.Equal(t, []string{"b"}, service.GetItem("c").RequiredBy())
+		assert.Len(t, service.Edges(), 2)
+		assert.Empty(t, service.Validate())
+
+		after, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, itemIDs(before), itemIDs(after))
+	})
+
+	t.Run("Nothing to remove", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+		_, _ = service.ApplyTransitiveReduction()
+
+		removed, err := service.ApplyTransitiveReduction()
+
+		require.NoError(t, err)
+		assert.Empty(t, removed)
+	})
+}
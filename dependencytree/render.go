@@ -0,0 +1,140 @@
+package dependencytree
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// TreeCharset holds the characters used to draw the branches of the tree.
+type TreeCharset struct {
+	First    string
+	Middle   string
+	Last     string
+	Vertical string
+	Space    string
+}
+
+var (
+	UnicodeCharset = TreeCharset{First: "┌─ ", Middle: "├─ ", Last: "└─ ", Vertical: "│  ", Space: "   "}
+	ASCIICharset   = TreeCharset{First: "+- ", Middle: "+- ", Last: "\\- ", Vertical: "|  ", Space: "   "}
+
+	// stringCharset is the charset String always used, kept so its output
+	// does not change.
+	stringCharset = TreeCharset{First: "┌─ ", Middle: "├─ ", Last: "└─ ", Vertical: "|  ", Space: "   "}
+)
+
+type RenderOptions struct {
+	// ASCII draws the branches with ASCII characters instead of Unicode ones.
+	ASCII bool
+	// MaxDepth limits how many levels are drawn, one only draws the root items,
+	// zero or less draws every level.
+	MaxDepth int
+	// ShowIDs writes the id of the item after its name.
+	ShowIDs bool
+	// ShowDependencies writes the names of the items every item depends on,
+	// leaving out its parents, optional dependencies end with a question mark.
+	ShowDependencies bool
+	// Colors highlights the output with ANSI escape codes.
+	Colors bool
+}
+
+// Render writes the tree returned by the last Build, one line per item, with
+// the children drawn under their parent. Shared items are drawn under every
+// one of their parents and marked with [shared].
+func (d *DependencyTreeService[T]) Render(w io.Writer, opts RenderOptions) error {
+	charset := UnicodeCharset
+	if opts.ASCII {
+		charset = ASCIICharset
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.render(w, opts, charset)
+}
+
+func (d *DependencyTreeService[T]) render(w io.Writer, opts RenderOptions, charset TreeCharset) error {
+	buffer := bytes.Buffer{}
+	d.renderItems(&buffer, d.tree, opts, charset, 1, "")
+
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+func (d *DependencyTreeService[T]) renderItems(buffer *bytes.Buffer, items []*DependencyTreeItem[T], opts RenderOptions, charset TreeCharset, level int, prefix string) {
+	if opts.MaxDepth > 0 && level > opts.MaxDepth {
+		return
+	}
+
+	for idx, item := range items {
+		branch := charset.Middle
+		childPrefix := prefix + charset.Vertical
+		switch {
+		case idx == len(items)-1:
+			branch = charset.Last
+			childPrefix = prefix + charset.Space
+		case idx == 0 && level == 1:
+			branch = charset.First
+		}
+
+		buffer.WriteString(colorize(opts, ansiDim, prefix+branch))
+		buffer.WriteString(colorize(opts, ansiBold, item.Name))
+		if item.IsShared() {
+			buffer.WriteString(colorize(opts, ansiDim, " [shared]"))
+		}
+		if opts.ShowIDs {
+			buffer.WriteString(colorize(opts, ansiCyan, " ("+item.ID+")"))
+		}
+		if opts.ShowDependencies {
+			if dependencies := d.renderDependencies(item); len(dependencies) > 0 {
+				buffer.WriteString(colorize(opts, ansiYellow, " -> "+strings.Join(dependencies, ", ")))
+			}
+		}
+		buffer.WriteString("\n")
+
+		d.renderItems(buffer, item.Children, opts, charset, level+1, childPrefix)
+	}
+}
+
+// renderDependencies returns the names of the items the item depends on,
+// leaving out its parents as the tree already shows them. Optional dependencies
+// are marked with a question mark and left out when they were not found.
+func (d *DependencyTreeService[T]) renderDependencies(item *DependencyTreeItem[T]) []string {
+	result := []string{}
+	for idx, dependency := range item.allDependencies() {
+		optional := idx >= len(item.IsDependentOn())
+		dependencyItem := d.getItem(dependency)
+		switch {
+		case dependencyItem == nil && optional:
+			continue
+		case dependencyItem == nil:
+			result = append(result, dependency)
+		case slices.Contains(item.Parents, dependencyItem):
+			continue
+		case optional:
+			result = append(result, dependencyItem.Name+"?")
+		default:
+			result = append(result, dependencyItem.Name)
+		}
+	}
+
+	return result
+}
+
+func colorize(opts RenderOptions, color string, value string) string {
+	if !opts.Colors || value == "" {
+		return value
+	}
+
+	return color + value + ansiReset
+}
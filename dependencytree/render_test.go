@@ -0,0 +1,144 @@
+package dependencytree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRenderTestService(t *testing.T) *DependencyTreeService[MockObject1] {
+	service := New[MockObject1]()
+	_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+	_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+	_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+	_, _ = service.AddItem("handlers", "Handlers", "api", MockObject1{id: "handlers"})
+	_, _ = service.AddItem("users", "Users", "handlers", MockObject1{id: "users"})
+	_, _ = service.AddItem("metrics", "Metrics", "api", MockObject1{id: "metrics"})
+	_ = service.DependsOn("api", "database")
+	_ = service.DependsOn("api", "cache")
+	_ = service.DependsOn("users", "database")
+
+	_, err := service.Build()
+	require.NoError(t, err)
+
+	return service
+}
+
+func TestRender(t *testing.T) {
+	t.Run("Unicode characters by default", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+
+		err := newRenderTestService(t).Render(&buffer, RenderOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, `┌─ Database
+├─ Cache
+└─ API
+   ├─ Handlers
+   │  └─ Users
+   └─ Metrics
+`, buffer.String())
+	})
+
+	t.Run("ASCII characters, ids and dependencies", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+
+		err := newRenderTestService(t).Render(&buffer, RenderOptions{ASCII: true, ShowIDs: true, ShowDependencies: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, `+- Database (database)
++- Cache (cache)
+\- API (api) -> Database, Cache
+   +- Handlers (handlers)
+   |  \- Users (users) -> Database
+   \- Metrics (metrics)
+`, buffer.String())
+	})
+
+	t.Run("Optional dependencies are marked", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("cache", "Cache", MockObject1{id: "cache"})
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_ = service.DependsOnOptional("api", "cache")
+		_ = service.DependsOnOptional("api", "missing")
+		_, err := service.Build()
+		require.NoError(t, err)
+		buffer := bytes.Buffer{}
+
+		err = service.Render(&buffer, RenderOptions{ShowDependencies: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "┌─ Cache\n└─ API -> Cache?\n", buffer.String())
+	})
+
+	t.Run("Shared items are drawn under every parent", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("worker", "Worker", MockObject1{id: "worker"})
+		_, _ = service.AddItem("cache", "Cache", "api", MockObject1{id: "cache"})
+		_ = service.AddParent("cache", "worker")
+		_, err := service.Build()
+		require.NoError(t, err)
+		buffer := bytes.Buffer{}
+
+		err = service.Render(&buffer, RenderOptions{ShowDependencies: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, `┌─ API
+│  └─ Cache [shared]
+└─ Worker
+   └─ Cache [shared]
+`, buffer.String())
+	})
+
+	t.Run("Max depth", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+
+		err := newRenderTestService(t).Render(&buffer, RenderOptions{MaxDepth: 2})
+
+		require.NoError(t, err)
+		assert.Equal(t, "┌─ Database\n├─ Cache\n└─ API\n   ├─ Handlers\n   └─ Metrics\n", buffer.String())
+	})
+
+	t.Run("Colors", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		_, err := service.Build()
+		require.NoError(t, err)
+		buffer := bytes.Buffer{}
+
+		err = service.Render(&buffer, RenderOptions{ShowIDs: true, Colors: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b[2m└─ \x1b[0m\x1b[1mDatabase\x1b[0m\x1b[36m (database)\x1b[0m\n", buffer.String())
+	})
+
+	t.Run("Does not write anything before the tree is built", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("database", "Database", MockObject1{id: "database"})
+		buffer := bytes.Buffer{}
+
+		err := service.Render(&buffer, RenderOptions{})
+
+		require.NoError(t, err)
+		assert.Empty(t, buffer.String())
+		assert.Empty(t, service.String())
+	})
+
+	t.Run("Returns the writer error", func(t *testing.T) {
+		err := newRenderTestService(t).Render(failingWriter{}, RenderOptions{})
+
+		assert.EqualError(t, err, "write failed")
+	})
+
+	t.Run("String keeps its characters", func(t *testing.T) {
+		assert.Equal(t, `┌─ Database
+├─ Cache
+└─ API
+   ├─ Handlers
+   |  └─ Users
+   └─ Metrics`, newRenderTestService(t).String())
+	})
+}
@@ -2,7 +2,7 @@ package dependencytree
 
 import (
 	"fmt"
-	"reflect"
+	"slices"
 	"strings"
 	"sync"
 
@@ -14,70 +14,288 @@ var (
 	lock                        = &sync.Mutex{}
 )
 
+// DependencyTreeService is safe for concurrent use, the items and the tree are
+// guarded by mu while the logging settings have their own lock so they can be
+// read while the tree is being built.
 type DependencyTreeService[T interface{}] struct {
-	logger   *log.LoggerService
-	debug    bool
-	verbose  bool
-	flatTree []*DependencyTreeItem[T]
-	tree     []*DependencyTreeItem[T]
+	name            string
+	logMu           sync.RWMutex
+	logger          *log.LoggerService
+	debug           bool
+	verbose         bool
+	mu              sync.RWMutex
+	caseInsensitive bool
+	codec           Codec[T]
+	flatTree        []*DependencyTreeItem[T]
+	tree            []*DependencyTreeItem[T]
+	byID            map[string]*DependencyTreeItem[T]
+	byName          map[string]*DependencyTreeItem[T]
+	positions       map[*DependencyTreeItem[T]]int
+	edges           map[edgeKey]Edge
+	version         uint64
+	builtVersion    uint64
+	sorted          bool
 }
 
+type serviceOptions struct {
+	name            string
+	logger          *log.LoggerService
+	debug           bool
+	verbose         bool
+	caseInsensitive bool
+}
+
+// ServiceOption configures a DependencyTreeService created with New.
+type ServiceOption func(*serviceOptions)
+
+func WithName(name string) ServiceOption {
+	return func(o *serviceOptions) {
+		o.name = name
+	}
+}
+
+func WithLogger(logger *log.LoggerService) ServiceOption {
+	return func(o *serviceOptions) {
+		o.logger = logger
+	}
+}
+
+func WithDebug(debug bool) ServiceOption {
+	return func(o *serviceOptions) {
+		o.debug = debug
+	}
+}
+
+func WithVerbose(verbose bool) ServiceOption {
+	return func(o *serviceOptions) {
+		o.verbose = verbose
+	}
+}
+
+// WithCaseInsensitive makes ids and names match regardless of their case.
+func WithCaseInsensitive(caseInsensitive bool) ServiceOption {
+	return func(o *serviceOptions) {
+		o.caseInsensitive = caseInsensitive
+	}
+}
+
+// New returns a new DependencyTreeService that is not shared with anyone else,
+// it is not registered in the global registry used by Get and GetNamed.
+func New[T interface{}](opts ...ServiceOption) *DependencyTreeService[T] {
+	options := serviceOptions{
+		name:            "",
+		logger:          nil,
+		debug:           false,
+		verbose:         false,
+		caseInsensitive: false,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.logger == nil {
+		options.logger = log.Get()
+	}
+
+	return &DependencyTreeService[T]{
+		name:            options.name,
+		debug:           options.debug,
+		verbose:         options.verbose,
+		logger:          options.logger,
+		caseInsensitive: options.caseInsensitive,
+		flatTree:        []*DependencyTreeItem[T]{},
+		tree:            []*DependencyTreeItem[T]{},
+		byID:            map[string]*DependencyTreeItem[T]{},
+		byName:          map[string]*DependencyTreeItem[T]{},
+		positions:       map[*DependencyTreeItem[T]]int{},
+	}
+}
+
+// Get returns the process wide DependencyTreeService for T, creating it on the
+// first call.
 func Get[T interface{}](v T) *DependencyTreeService[T] {
+	return GetNamed[T]("")
+}
+
+// GetNamed returns the process wide DependencyTreeService for T registered with
+// the given name, creating it on the first call. This allows one process to
+// hold several trees of the same type.
+func GetNamed[T interface{}](name string) *DependencyTreeService[T] {
 	lock.Lock()
+	defer lock.Unlock()
+
 	for _, item := range globalDependencyTreeService {
-		mPointer := &DependencyTreeService[T]{}
-		itemKind := reflect.TypeOf(item).String()
-		pointerKind := reflect.TypeOf(mPointer).String()
-		if pointerKind == itemKind {
-			lock.Unlock()
-			return item.(*DependencyTreeService[T])
+		if service, ok := item.(*DependencyTreeService[T]); ok && service.name == name {
+			return service
 		}
 	}
 
-	newTreeType := DependencyTreeService[T]{
-		debug:    false,
-		verbose:  false,
-		logger:   log.Get(),
-		flatTree: []*DependencyTreeItem[T]{},
-		tree:     []*DependencyTreeItem[T]{},
-	}
-	globalDependencyTreeService = append(globalDependencyTreeService, &newTreeType)
+	newTreeType := New[T](WithName(name))
+	globalDependencyTreeService = append(globalDependencyTreeService, newTreeType)
 
-	lock.Unlock()
+	return newTreeType
+}
 
-	return &newTreeType
+func (d *DependencyTreeService[T]) Name() string {
+	return d.name
 }
 
 func (d *DependencyTreeService[T]) String() string {
-	lines := d.printTree(d.tree, 0, "", false)
-	return strings.Join(lines, "\n")
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.string()
+}
+
+func (d *DependencyTreeService[T]) string() string {
+	sb := strings.Builder{}
+	_ = d.render(&sb, RenderOptions{}, stringCharset)
+	return strings.TrimSuffix(sb.String(), "\n")
 }
 
 func (d *DependencyTreeService[T]) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	d.flatTree = []*DependencyTreeItem[T]{}
 	d.tree = []*DependencyTreeItem[T]{}
+	d.edges = nil
+	d.sorted = false
+	d.reindex()
+	d.changed()
+}
+
+// Version returns a counter that is increased every time the items or their
+// edges are changed through the service.
+func (d *DependencyTreeService[T]) Version() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.version
+}
+
+// Dirty reports whether the items or their edges were changed through the
+// service since the last Build, in which case the tree needs to be built again.
+// Changes made directly on the items are not tracked.
+func (d *DependencyTreeService[T]) Dirty() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.version != d.builtVersion
+}
+
+// changed records a change to the items, the caller must hold the write lock.
+func (d *DependencyTreeService[T]) changed() {
+	d.version += 1
+}
+
+func (d *DependencyTreeService[T]) IsCaseInsensitive() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.caseInsensitive
+}
+
+// SetCaseInsensitive changes how ids and names are matched, by default they
+// need to match exactly. Ignoring the case fails when two items have ids or
+// names that only differ in case, the setting is then left unchanged.
+func (d *DependencyTreeService[T]) SetCaseInsensitive(caseInsensitive bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if caseInsensitive && !d.caseInsensitive {
+		ids := make(map[string]*DependencyTreeItem[T], len(d.flatTree))
+		names := make(map[string]*DependencyTreeItem[T], len(d.flatTree))
+		for _, item := range d.flatTree {
+			if other, ok := ids[strings.ToLower(item.ID)]; ok {
+				return fmt.Errorf("items with id %v and %v only differ in case", other.ID, item.ID)
+			}
+			if other, ok := names[strings.ToLower(item.Name)]; ok {
+				return fmt.Errorf("items with name %v and %v only differ in case", other.Name, item.Name)
+			}
+			ids[strings.ToLower(item.ID)] = item
+			names[strings.ToLower(item.Name)] = item
+		}
+	}
+
+	d.caseInsensitive = caseInsensitive
+	d.reindex()
+	d.changed()
+	return nil
+}
+
+func (d *DependencyTreeService[T]) key(value string) string {
+	if d.caseInsensitive {
+		return strings.ToLower(value)
+	}
+
+	return value
+}
+
+func (d *DependencyTreeService[T]) equal(a, b string) bool {
+	if d.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+// reindex rebuilds the id, name and position indexes from the flat tree, the
+// caller must hold the write lock.
+func (d *DependencyTreeService[T]) reindex() {
+	d.byID = make(map[string]*DependencyTreeItem[T], len(d.flatTree))
+	d.byName = make(map[string]*DependencyTreeItem[T], len(d.flatTree))
+	d.positions = make(map[*DependencyTreeItem[T]]int, len(d.flatTree))
+	for idx, item := range d.flatTree {
+		item.caseInsensitive = d.caseInsensitive
+		d.byID[d.key(item.ID)] = item
+		d.byName[d.key(item.Name)] = item
+		d.positions[item] = idx
+	}
 }
 
 func (d *DependencyTreeService[T]) IsDebug() bool {
+	d.logMu.RLock()
+	defer d.logMu.RUnlock()
+
 	return d.debug
 }
 
 func (d *DependencyTreeService[T]) IsVerbose() bool {
+	d.logMu.RLock()
+	defer d.logMu.RUnlock()
+
 	return d.verbose
 }
 
 func (d *DependencyTreeService[T]) SetDebug(debug bool) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+
 	d.debug = debug
 }
 
 func (d *DependencyTreeService[T]) SetVerbose(verbose bool) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+
 	d.verbose = verbose
 }
 
 func (d *DependencyTreeService[T]) SetLogger(logger *log.LoggerService) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+
 	d.logger = logger
 }
 
+func (d *DependencyTreeService[T]) getLogger() *log.LoggerService {
+	d.logMu.RLock()
+	defer d.logMu.RUnlock()
+
+	return d.logger
+}
+
 func (d *DependencyTreeService[T]) AddRootItem(id string, name string, value T) (*DependencyTreeItem[T], error) {
 	teeItem, err := NewDependencyTreeItem[T](id, name, value)
 	if err != nil {
@@ -94,19 +312,98 @@ func (d *DependencyTreeService[T]) AddRootItem(id string, name string, value T)
 }
 
 func (d *DependencyTreeService[T]) DependsOn(id string, dependencyId string) error {
-	item := d.GetItem(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(id)
 	if item == nil {
 		return fmt.Errorf("item %v not found", id)
 	}
 
-	dependency := d.GetItem(dependencyId)
+	dependency := d.getItem(dependencyId)
 	if dependency == nil {
 		return fmt.Errorf("dependency %v not found", dependencyId)
 	}
 
 	item.isDependentOn = append(item.isDependentOn, dependency.ID)
 	dependency.requiredBy = append(dependency.requiredBy, item.ID)
+	d.changed()
+
+	return nil
+}
 
+// DependsOnOptional makes the item come after the dependency only if the
+// dependency exists when the tree is built, so it does not need to be added yet.
+// The item is not added to RequiredBy and is not skipped if the dependency fails.
+func (d *DependencyTreeService[T]) DependsOnOptional(id string, dependencyId string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(id)
+	if item == nil {
+		return fmt.Errorf("item %v not found", id)
+	}
+
+	return d.addEdge(item, dependencyId, OptionalEdge)
+}
+
+// After makes the item come after the other one when both are part of the tree
+// once it is built. Unlike DependsOn it only changes the order, the item is not
+// added to RequiredBy and is not skipped if the other one fails.
+func (d *DependencyTreeService[T]) After(id string, otherId string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(id)
+	if item == nil {
+		return fmt.Errorf("item %v not found", id)
+	}
+
+	return d.addEdge(item, otherId, AfterEdge)
+}
+
+// Before makes the item come before the other one when both are part of the
+// tree once it is built. It only changes the order, like After.
+func (d *DependencyTreeService[T]) Before(id string, otherId string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(id)
+	if item == nil {
+		return fmt.Errorf("item %v not found", id)
+	}
+
+	return d.addEdge(item, otherId, BeforeEdge)
+}
+
+// AddParent adds an item to the group of another parent, the item is placed
+// after every one of its parents and rendered under each of them.
+func (d *DependencyTreeService[T]) AddParent(id string, parentId string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item := d.getItem(id)
+	if item == nil {
+		return fmt.Errorf("item %v not found", id)
+	}
+
+	parent := d.getItem(parentId)
+	if parent == nil {
+		return fmt.Errorf("parent %v not found", parentId)
+	}
+	if parent == item {
+		return fmt.Errorf("item %v cannot be its own parent", id)
+	}
+
+	if current, _ := d.validateParent(item); current == parent {
+		return fmt.Errorf("parent %v already exists", parentId)
+	}
+
+	if err := item.AddParent(parent.ID); err != nil {
+		return err
+	}
+
+	d.changed()
 	return nil
 }
 
@@ -127,62 +424,165 @@ func (d *DependencyTreeService[T]) AddItem(id string, name string, parent string
 }
 
 func (d *DependencyTreeService[T]) AddDependencyTreeItem(item *DependencyTreeItem[T]) error {
-	for _, i := range d.flatTree {
-		if strings.EqualFold(i.ID, item.ID) || strings.EqualFold(i.Name, item.Name) {
-			return fmt.Errorf("item with id %v already exists", item.ID)
-		}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.byID == nil {
+		d.reindex()
+	}
+
+	_, idExists := d.byID[d.key(item.ID)]
+	_, nameExists := d.byName[d.key(item.Name)]
+	if idExists || nameExists {
+		return fmt.Errorf("item with id %v already exists", item.ID)
 	}
 
+	item.caseInsensitive = d.caseInsensitive
+	d.byID[d.key(item.ID)] = item
+	d.byName[d.key(item.Name)] = item
+	d.positions[item] = len(d.flatTree)
 	d.flatTree = append(d.flatTree, item)
+	d.changed()
 	return nil
 }
 
+// RemoveDependencyTreeItem removes the item from the service, an item that is
+// not held by the service removes the item with the same id or name.
 func (d *DependencyTreeService[T]) RemoveDependencyTreeItem(item *DependencyTreeItem[T]) error {
-	for idx, i := range d.flatTree {
-		if strings.EqualFold(i.ID, item.ID) || strings.EqualFold(i.Name, item.Name) {
-			d.flatTree = append(d.flatTree[:idx], d.flatTree[idx+1:]...)
-			return nil
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	existing := item
+	if _, ok := d.positions[item]; !ok {
+		existing = d.getItem(item.ID)
+		if existing == nil {
+			existing = d.getItem(item.Name)
+		}
+	}
+	if existing == nil {
+		return fmt.Errorf("item with id %v not found", item.ID)
+	}
+
+	// copying the items so slices handed out before are left untouched
+	idx := d.positions[existing]
+	flatTree := make([]*DependencyTreeItem[T], 0, len(d.flatTree)-1)
+	flatTree = append(flatTree, d.flatTree[:idx]...)
+	d.flatTree = append(flatTree, d.flatTree[idx+1:]...)
+	d.reindex()
+
+	for key := range d.edges {
+		if key.from == d.key(existing.ID) || key.to == d.key(existing.ID) || key.to == d.key(existing.Name) {
+			delete(d.edges, key)
 		}
 	}
 
-	return fmt.Errorf("item with id %v not found", item.ID)
+	d.detach(existing)
+	d.changed()
+	return nil
 }
 
-func (d *DependencyTreeService[T]) GetItem(nameOrId string) *DependencyTreeItem[T] {
+// detach removes what Build linked between the removed item and the other
+// items, so the children of a removed parent are treated as if it was never
+// added. Dependencies declared on the removed item are left, Build reports them
+// as missing. The caller must hold the write lock.
+func (d *DependencyTreeService[T]) detach(removed *DependencyTreeItem[T]) {
+	isRemoved := func(idOrName string) bool {
+		return d.equal(idOrName, removed.ID) || d.equal(idOrName, removed.Name)
+	}
+
 	for _, item := range d.flatTree {
-		if strings.EqualFold(item.ID, nameOrId) || strings.EqualFold(item.Name, nameOrId) {
-			return item
+		if slices.Contains(item.Parents, removed) {
+			if item.Parent == removed {
+				item.Parent = nil
+			}
+			item.Parents = slices.DeleteFunc(slices.Clone(item.Parents), func(parent *DependencyTreeItem[T]) bool {
+				return parent == removed
+			})
+			if slices.ContainsFunc(item.parentDependencies, isRemoved) {
+				item.parentDependencies = slices.DeleteFunc(slices.Clone(item.parentDependencies), isRemoved)
+				item.isDependentOn = slices.DeleteFunc(slices.Clone(item.isDependentOn), isRemoved)
+			}
+		}
+
+		if slices.Contains(item.Children, removed) {
+			item.Children = slices.DeleteFunc(slices.Clone(item.Children), func(child *DependencyTreeItem[T]) bool {
+				return child == removed
+			})
 		}
+		if slices.ContainsFunc(item.requiredBy, isRemoved) {
+			item.requiredBy = slices.DeleteFunc(slices.Clone(item.requiredBy), isRemoved)
+		}
+	}
+
+	// the item can be added again, so it is linked again by the next Build
+	for _, parent := range removed.Parents {
+		removed.isDependentOn = slices.DeleteFunc(slices.Clone(removed.isDependentOn), func(dependency string) bool {
+			return slices.Contains(removed.parentDependencies, dependency) && d.equal(dependency, parent.ID)
+		})
+	}
+	removed.parentDependencies = []string{}
+	removed.Parent = nil
+	removed.Parents = []*DependencyTreeItem[T]{}
+	removed.Children = []*DependencyTreeItem[T]{}
+}
+
+func (d *DependencyTreeService[T]) GetItem(nameOrId string) *DependencyTreeItem[T] {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getItem(nameOrId)
+}
+
+func (d *DependencyTreeService[T]) getItem(nameOrId string) *DependencyTreeItem[T] {
+	key := d.key(nameOrId)
+	if item, ok := d.byID[key]; ok {
+		return item
+	}
+	if item, ok := d.byName[key]; ok {
+		return item
 	}
 
 	return nil
 }
 
 func (d *DependencyTreeService[T]) GetItemIndex(nameOrId string) (int, error) {
-	for idx, item := range d.flatTree {
-		if strings.EqualFold(item.ID, nameOrId) || strings.EqualFold(item.Name, nameOrId) {
-			return idx, nil
-		}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	item := d.getItem(nameOrId)
+	if item == nil {
+		return -1, fmt.Errorf("item with id %v not found", nameOrId)
 	}
 
-	return -1, fmt.Errorf("item with id %v not found", nameOrId)
+	return d.positions[item], nil
 }
 
 func (d *DependencyTreeService[T]) GetItemChildren(nameOrId string) []*DependencyTreeItem[T] {
-	for _, item := range d.flatTree {
-		if strings.EqualFold(item.ID, nameOrId) || strings.EqualFold(item.Name, nameOrId) {
-			return item.Children
-		}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if item := d.getItem(nameOrId); item != nil {
+		return item.Children
 	}
 
 	return []*DependencyTreeItem[T]{}
 }
 
 func (d *DependencyTreeService[T]) GetItemByParent(parent string) []*DependencyTreeItem[T] {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getItemByParent(parent)
+}
+
+func (d *DependencyTreeService[T]) getItemByParent(parent string) []*DependencyTreeItem[T] {
 	result := []*DependencyTreeItem[T]{}
 
 	for _, item := range d.flatTree {
-		if strings.EqualFold(item.GetParentName(), parent) || strings.EqualFold(item.GetParentId(), parent) {
+		isAdditional := slices.ContainsFunc(item.AdditionalParents(), func(additional string) bool {
+			return d.equal(additional, parent)
+		})
+		if d.equal(item.GetParentName(), parent) || d.equal(item.GetParentId(), parent) || isAdditional {
 			result = append(result, item)
 		}
 	}
@@ -190,28 +590,45 @@ func (d *DependencyTreeService[T]) GetItemByParent(parent string) []*DependencyT
 	return result
 }
 
+// GetItemDependencies returns the children of the item, it is kept for
+// compatibility, use TransitiveDependencies to get the items it depends on.
 func (d *DependencyTreeService[T]) GetItemDependencies(nameOrId string) []*DependencyTreeItem[T] {
-	for _, item := range d.flatTree {
-		if strings.EqualFold(item.ID, nameOrId) || strings.EqualFold(item.Name, nameOrId) {
-			return item.Children
-		}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if item := d.getItem(nameOrId); item != nil {
+		return item.Children
 	}
 
 	return []*DependencyTreeItem[T]{}
 }
 
 func (d *DependencyTreeService[T]) Tree() []*DependencyTreeItem[T] {
-	buildTree := d.buildTree("root")
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	buildTree := d.buildTree()
 	d.tree = buildTree
 	return d.tree
 }
 
+// FlatTree returns a copy of the items, so it can be used while other
+// goroutines change the service.
 func (d *DependencyTreeService[T]) FlatTree() []*DependencyTreeItem[T] {
-	return d.flatTree
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]*DependencyTreeItem[T], len(d.flatTree))
+	copy(result, d.flatTree)
+	return result
 }
 
 func (d *DependencyTreeService[T]) PrintFlatTree() {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	logger := d.getLogger()
 	for _, item := range d.flatTree {
-		d.logger.Info("Id: %v, Name: %v", item.ID, item.Name)
+		logger.Info("Id: %v, Name: %v", item.ID, item.Name)
 	}
 }
@@ -1,6 +1,10 @@
 package dependencytree
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	log "github.com/cjlapao/common-go-logger"
@@ -114,6 +118,82 @@ func TestGet(t *testing.T) {
 	})
 }
 
+func TestNew(t *testing.T) {
+	t.Run("New returns isolated services", func(t *testing.T) {
+		service1 := New[MockObject1]()
+		service2 := New[MockObject1]()
+		_, _ = service1.AddRootItem("test", "test", MockObject1{id: "test"})
+
+		assert.NotSame(t, service1, service2)
+		assert.Len(t, service1.FlatTree(), 1)
+		assert.Len(t, service2.FlatTree(), 0)
+		assert.NotNil(t, service1.logger)
+	})
+
+	t.Run("New is not registered globally", func(t *testing.T) {
+		lock.Lock()
+		globalDependencyTreeService = nil
+		lock.Unlock()
+
+		service := New[MockObject1]()
+
+		assert.NotSame(t, service, Get(MockObject1{}))
+	})
+
+	t.Run("New with options", func(t *testing.T) {
+		logger := &log.LoggerService{}
+		service := New[MockObject1](WithName("plugins"), WithLogger(logger), WithDebug(true), WithVerbose(true))
+
+		assert.Equal(t, "plugins", service.Name())
+		assert.Same(t, logger, service.logger)
+		assert.True(t, service.IsDebug())
+		assert.True(t, service.IsVerbose())
+	})
+}
+
+func TestGetNamed(t *testing.T) {
+	t.Run("Same name returns the same service", func(t *testing.T) {
+		lock.Lock()
+		globalDependencyTreeService = nil
+		lock.Unlock()
+
+		service1 := GetNamed[MockObject1]("plugins")
+		service2 := GetNamed[MockObject1]("plugins")
+
+		assert.Same(t, service1, service2)
+		assert.Equal(t, "plugins", service1.Name())
+	})
+
+	t.Run("Different names return different services", func(t *testing.T) {
+		lock.Lock()
+		globalDependencyTreeService = nil
+		lock.Unlock()
+
+		plugins := GetNamed[MockObject1]("plugins")
+		modules := GetNamed[MockObject1]("modules")
+		_, _ = plugins.AddRootItem("test", "test", MockObject1{id: "test"})
+
+		assert.NotSame(t, plugins, modules)
+		assert.Len(t, modules.FlatTree(), 0)
+		assert.NotSame(t, plugins, Get(MockObject1{}))
+	})
+
+	t.Run("Same name with different types", func(t *testing.T) {
+		lock.Lock()
+		globalDependencyTreeService = nil
+		lock.Unlock()
+
+		service1 := GetNamed[MockObject1]("plugins")
+		service2 := GetNamed[MockObject2]("plugins")
+
+		assert.NotNil(t, service1)
+		assert.NotNil(t, service2)
+		lock.Lock()
+		assert.Len(t, globalDependencyTreeService, 2)
+		lock.Unlock()
+	})
+}
+
 func TestSetDebug(t *testing.T) {
 	t.Run("Set debug modefor DependencyTreeService[DependencyTreeObjectMock]", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
@@ -379,6 +459,57 @@ func TestRemove(t *testing.T) {
 		assert.Equal(t, service.flatTree[0].obj.ID(), mockClass2.ID())
 	})
 
+	t.Run("Remove a parent after a build", func(t *testing.T) {
+		service := New[MockObject1]()
+		parent, _ := service.AddRootItem("p", "P", MockObject1{id: "p"})
+		child, _ := service.AddItem("c1", "C1", "p", MockObject1{id: "c1"})
+		_, _ = service.AddRootItem("x", "X", MockObject1{id: "x"})
+		_ = service.DependsOn("x", "p")
+		require.NoError(t, service.AddEdge(Edge{From: "x", To: "p", Kind: ExplicitEdge, Weight: 2}))
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		require.NoError(t, service.RemoveDependencyTreeItem(parent))
+
+		assert.Nil(t, child.Parent)
+		assert.Empty(t, child.Parents)
+		assert.Empty(t, child.IsDependentOn())
+		assert.Empty(t, parent.Children)
+		edges := service.Edges().To("p")
+		require.Len(t, edges, 1)
+		assert.Zero(t, edges[0].Weight)
+		_, err = service.Build()
+		assert.EqualError(t, err, "dependency on p of service X was not found in the context configuration")
+
+		_ = service.RemoveDependencyTreeItem(service.GetItem("x"))
+		values, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c1"}, itemIDs(values))
+
+		require.NoError(t, service.AddDependencyTreeItem(parent))
+		values, err = service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p", "c1"}, itemIDs(values))
+		assert.Equal(t, []*DependencyTreeItem[MockObject1]{child}, parent.Children)
+		assert.Equal(t, []string{"p"}, child.IsDependentOn())
+	})
+
+	t.Run("Remove a child after a build", func(t *testing.T) {
+		service := New[MockObject1]()
+		parent, _ := service.AddRootItem("p", "P", MockObject1{id: "p"})
+		child, _ := service.AddItem("c1", "C1", "p", MockObject1{id: "c1"})
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		require.NoError(t, service.RemoveDependencyTreeItem(child))
+
+		assert.Empty(t, parent.Children)
+		assert.Empty(t, parent.RequiredBy())
+		values, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p"}, itemIDs(values))
+	})
+
 	t.Run("Remove non-existing item", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
 		_ = service.AddDependencyTreeItem(&mockTreeObject1)
@@ -445,6 +576,7 @@ func TestGetItem(t *testing.T) {
 
 	t.Run("Get existing item by name (case-insensitive)", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
+		require.NoError(t, service.SetCaseInsensitive(true))
 		_ = service.AddDependencyTreeItem(&mockTreeObject1)
 		_ = service.AddDependencyTreeItem(&mockTreeObject2)
 
@@ -454,8 +586,19 @@ func TestGetItem(t *testing.T) {
 		assert.Equal(t, item.ID, mockClass2.ID())
 	})
 
+	t.Run("Get existing item by name is case-sensitive by default", func(t *testing.T) {
+		service := &DependencyTreeService[MockObject1]{}
+		_ = service.AddDependencyTreeItem(&mockTreeObject1)
+		_ = service.AddDependencyTreeItem(&mockTreeObject2)
+
+		assert.Nil(t, service.GetItem("Test2"))
+		assert.NotNil(t, service.GetItem("test2"))
+		assert.False(t, service.IsCaseInsensitive())
+	})
+
 	t.Run("Get non-existing item by name (case-insensitive)", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
+		require.NoError(t, service.SetCaseInsensitive(true))
 		_ = service.AddDependencyTreeItem(&mockTreeObject1)
 		_ = service.AddDependencyTreeItem(&mockTreeObject2)
 
@@ -465,6 +608,64 @@ func TestGetItem(t *testing.T) {
 	})
 }
 
+func TestCaseSetting(t *testing.T) {
+	t.Run("Items that only differ in case are distinct", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "lower", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("x", "dependent", MockObject1{id: "x"})
+		_, err := service.AddRootItem("A", "upper", MockObject1{id: "A"})
+		require.NoError(t, err)
+
+		require.NoError(t, service.DependsOnOptional("x", "a"))
+		require.NoError(t, service.DependsOnOptional("x", "A"))
+		require.NoError(t, service.After("x", "a"))
+		require.NoError(t, service.After("x", "A"))
+
+		edges := service.Edges()
+		assert.Len(t, edges.To("A"), 2)
+		assert.Empty(t, edges.From("X"))
+
+		report, err := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			return nil
+		}).Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "A", report.Get("A").Item.ID)
+		assert.Equal(t, "a", report.Get("a").Item.ID)
+	})
+
+	t.Run("Case-insensitive services ignore the case", func(t *testing.T) {
+		service := New[MockObject1](WithCaseInsensitive(true))
+		_, _ = service.AddRootItem("a", "lower", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("x", "dependent", MockObject1{id: "x"})
+
+		require.NoError(t, service.DependsOnOptional("x", "a"))
+		assert.EqualError(t, service.DependsOnOptional("x", "A"), "item a already exists")
+
+		edges := service.Edges()
+		assert.Len(t, edges.From("X"), 1)
+		assert.Len(t, edges.To("A"), 1)
+
+		report, err := NewExecutor(service, func(ctx context.Context, item *DependencyTreeItem[MockObject1]) error {
+			return nil
+		}).Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "a", report.Get("A").Item.ID)
+	})
+
+	t.Run("Items that only differ in case keep the case setting", func(t *testing.T) {
+		service := New[MockObject1]()
+		lower, _ := service.AddRootItem("a", "lower", MockObject1{id: "a"})
+		upper, _ := service.AddRootItem("A", "upper", MockObject1{id: "A"})
+
+		assert.EqualError(t, service.SetCaseInsensitive(true), "items with id a and A only differ in case")
+		assert.False(t, service.IsCaseInsensitive())
+		assert.Same(t, lower, service.GetItem("a"))
+
+		require.NoError(t, service.RemoveDependencyTreeItem(lower))
+		assert.Equal(t, []*DependencyTreeItem[MockObject1]{upper}, service.FlatTree())
+	})
+}
+
 func TestGetParentItems(t *testing.T) {
 	mockClass1 := MockObject1{
 		id:              "test",
@@ -561,6 +762,7 @@ func TestGetItemChildren(t *testing.T) {
 
 	t.Run("Get children by name (case-insensitive)", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
+		require.NoError(t, service.SetCaseInsensitive(true))
 		_ = service.AddDependencyTreeItem(&mockTreeObject1)
 		_ = service.AddDependencyTreeItem(&mockTreeObject2)
 
@@ -672,6 +874,7 @@ func TestGetItemDependencies(t *testing.T) {
 
 	t.Run("Get item dependencies with case-insensitive search", func(t *testing.T) {
 		service := &DependencyTreeService[MockObject1]{}
+		require.NoError(t, service.SetCaseInsensitive(true))
 		_ = service.AddDependencyTreeItem(&mockTreeObject1)
 		_ = service.AddDependencyTreeItem(&mockTreeObject2)
 
@@ -720,6 +923,128 @@ func TestTree(t *testing.T) {
 		assert.Equal(t, tree[0].obj, mockTreeObject.obj)
 		assert.Equal(t, tree[0].requiredBy, mockTreeObject.requiredBy)
 	})
+
+	t.Run("Items with a missing parent are root items", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("api", "API", MockObject1{id: "api"})
+		_, _ = service.AddItem("orphan", "Orphan", "missing", MockObject1{id: "orphan"})
+		_, err := service.Build()
+		require.NoError(t, err)
+
+		tree := service.Tree()
+
+		require.Len(t, tree, 2)
+		assert.Equal(t, "orphan", tree[1].ID)
+		assert.Equal(t, MissingParent, service.Validate()[0].Kind)
+		assert.Contains(t, service.String(), "Orphan")
+		buffer := bytes.Buffer{}
+		require.NoError(t, service.Render(&buffer, RenderOptions{ASCII: true}))
+		assert.Equal(t, "+- API\n\\- Orphan\n", buffer.String())
+	})
+}
+
+func TestAddParent(t *testing.T) {
+	newService := func() *DependencyTreeService[MockObject1] {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		_, _ = service.AddRootItem("worker", "worker", MockObject1{id: "worker"})
+		_, _ = service.AddItem("cache", "cache", "api", MockObject1{id: "cache"})
+		return service
+	}
+
+	t.Run("Shared item is a child of every parent", func(t *testing.T) {
+		service := newService()
+		require.NoError(t, service.AddParent("cache", "worker"))
+
+		values, err := service.Build()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker", "cache"}, itemIDs(values))
+		cache := service.GetItem("cache")
+		assert.True(t, cache.IsShared())
+		assert.Equal(t, []string{"api", "worker"}, itemIDs(cache.Parents))
+		assert.Equal(t, "api", cache.GetParentId())
+
+		tree := service.Tree()
+		require.Len(t, tree, 2)
+		assert.Equal(t, []string{"cache"}, itemIDs(tree[0].Children))
+		assert.Equal(t, []string{"cache"}, itemIDs(tree[1].Children))
+		assert.Equal(t, []string{"cache"}, itemIDs(service.GetItemByParent("worker")))
+	})
+
+	t.Run("Returns errors", func(t *testing.T) {
+		service := newService()
+
+		assert.EqualError(t, service.AddParent("missing", "worker"), "item missing not found")
+		assert.EqualError(t, service.AddParent("cache", "missing"), "parent missing not found")
+		assert.EqualError(t, service.AddParent("cache", "cache"), "item cache cannot be its own parent")
+		assert.EqualError(t, service.AddParent("cache", "api"), "parent api already exists")
+		require.NoError(t, service.AddParent("cache", "worker"))
+		assert.EqualError(t, service.AddParent("cache", "worker"), "parent worker already exists")
+	})
+
+	t.Run("Reports the parents that conflict", func(t *testing.T) {
+		service := newService()
+		_, _ = service.AddItem("jobs", "jobs", "cache", MockObject1{id: "jobs"})
+		require.NoError(t, service.AddParent("cache", "jobs"))
+
+		_, err := service.Build()
+
+		assert.EqualError(t, err, "item cache cannot be placed after all of its parents, it is also an ancestor of jobs")
+	})
+}
+
+func TestVersion(t *testing.T) {
+	t.Run("Changes make the service dirty until it is built", func(t *testing.T) {
+		service := New[MockObject1]()
+		assert.Equal(t, uint64(0), service.Version())
+		assert.False(t, service.Dirty())
+
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		assert.Equal(t, uint64(2), service.Version())
+		assert.True(t, service.Dirty())
+
+		_, err := service.Build()
+		require.NoError(t, err)
+		assert.False(t, service.Dirty())
+		assert.Equal(t, uint64(2), service.Version())
+
+		_ = service.DependsOn("a", "b")
+		assert.True(t, service.Dirty())
+		assert.Equal(t, uint64(3), service.Version())
+
+		_, _ = service.Build()
+		require.NoError(t, service.RemoveDependencyTreeItem(service.GetItem("a")))
+		assert.True(t, service.Dirty())
+	})
+
+	t.Run("Failed changes and reads do not change the version", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		version := service.Version()
+
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_ = service.DependsOn("a", "missing")
+		_ = service.After("missing", "a")
+		_ = service.GetItem("a")
+		_ = service.Validate()
+
+		assert.Equal(t, version, service.Version())
+	})
+
+	t.Run("Failed builds leave the service dirty", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "a")
+
+		_, err := service.Build()
+
+		require.Error(t, err)
+		assert.True(t, service.Dirty())
+	})
 }
 
 func TestFlatTree(t *testing.T) {
@@ -746,3 +1071,141 @@ func TestFlatTree(t *testing.T) {
 		assert.Equal(t, flatTree[0].obj.ID(), mockClass.ID())
 	})
 }
+
+func TestConcurrentAccess(t *testing.T) {
+	t.Run("Items registered from several goroutines", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, err := service.AddRootItem("core", "core", MockObject1{id: "core"})
+		require.NoError(t, err)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id := fmt.Sprintf("plugin_%d", i)
+				_, err := service.AddItem(id, id, "core", MockObject1{id: id})
+				assert.NoError(t, err)
+				assert.NoError(t, service.DependsOn(id, "core"))
+				assert.NotNil(t, service.GetItem(id))
+				_, _ = service.GetItemIndex(id)
+				_ = service.GetItemChildren("core")
+				_ = service.GetItemByParent("core")
+				_ = service.FlatTree()
+				_, err = service.Build()
+				assert.NoError(t, err)
+				_ = service.Tree()
+				service.SetVerbose(i%2 == 0)
+				_ = service.IsDebug()
+			}(i)
+		}
+		wg.Wait()
+
+		values, err := service.Build()
+		require.NoError(t, err)
+		assert.Len(t, values, 21)
+		assert.Equal(t, "core", values[0].ID)
+		assert.Len(t, service.GetItemChildren("core"), 20)
+	})
+
+	t.Run("Items removed while building", func(t *testing.T) {
+		service := New[MockObject1]()
+		items := []*DependencyTreeItem[MockObject1]{}
+		for i := 0; i < 20; i++ {
+			id := fmt.Sprintf("item_%d", i)
+			item, err := service.AddRootItem(id, id, MockObject1{id: id})
+			require.NoError(t, err)
+			items = append(items, item)
+		}
+
+		wg := sync.WaitGroup{}
+		for _, item := range items {
+			wg.Add(2)
+			go func(item *DependencyTreeItem[MockObject1]) {
+				defer wg.Done()
+				assert.NoError(t, service.RemoveDependencyTreeItem(item))
+			}(item)
+			go func() {
+				defer wg.Done()
+				_, err := service.BuildLayers()
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Empty(t, service.FlatTree())
+	})
+
+	t.Run("Parents added while building", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("core", "core", MockObject1{id: "core"})
+		_, _ = service.AddRootItem("shared", "shared", MockObject1{id: "shared"})
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				id := fmt.Sprintf("plugin_%d", i)
+				_, err := service.AddItem(id, id, "core", MockObject1{id: id})
+				assert.NoError(t, err)
+				_, err = service.Build()
+				assert.NoError(t, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_ = service.AddParent(fmt.Sprintf("plugin_%d", i), "shared")
+				_, err := service.Build()
+				assert.NoError(t, err)
+			}
+		}()
+		wg.Wait()
+
+		_, err := service.Build()
+		require.NoError(t, err)
+	})
+
+	t.Run("Callbacks can use the service", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("parent", "parent", MockObject1{id: "parent"})
+		_, _ = service.AddRootItem("other", "other", MockObject1{id: "other"})
+		child, _ := service.AddItem("child", "child", "parent", MockObject1{id: "child"})
+		called := false
+		child.CallBack = func() {
+			called = service.GetItem("parent") != nil
+		}
+
+		_, err := service.Build()
+
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+}
+
+func BenchmarkGetItem(b *testing.B) {
+	service := New[MockObject1]()
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("item_%d", i)
+		_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = service.GetItem("item_9999")
+	}
+}
+
+func BenchmarkGetItemCaseInsensitive(b *testing.B) {
+	service := New[MockObject1](WithCaseInsensitive(true))
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("item_%d", i)
+		_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = service.GetItem("ITEM_9999")
+	}
+}
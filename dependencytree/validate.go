@@ -0,0 +1,361 @@
+package dependencytree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+type DiagnosticKind string
+
+const (
+	// MissingDependency is reported when an item depends on an item that does not exist.
+	MissingDependency DiagnosticKind = "missing-dependency"
+	// IgnoredOptionalDependency is reported when an item optionally depends on an
+	// item that does not exist, Build ignores these dependencies.
+	IgnoredOptionalDependency DiagnosticKind = "ignored-optional-dependency"
+	// IgnoredOrderingHint is reported when an item is ordered after or before an
+	// item that does not exist, Build ignores these hints.
+	IgnoredOrderingHint DiagnosticKind = "ignored-ordering-hint"
+	// MissingParent is reported when the parent of an item does not exist, Build
+	// treats these items as root items.
+	MissingParent DiagnosticKind = "missing-parent"
+	// SelfDependency is reported when an item depends on itself or is ordered
+	// after or before itself.
+	SelfDependency DiagnosticKind = "self-dependency"
+	// DuplicateDependency is reported when an item depends on the same item twice.
+	DuplicateDependency DiagnosticKind = "duplicate-dependency"
+	// RedundantDependency is reported when an item depends on an item it already
+	// requires through another path, ApplyTransitiveReduction removes them.
+	RedundantDependency DiagnosticKind = "redundant-dependency"
+	// CircularDependency is reported for every cycle found in the dependencies,
+	// including the implicit dependency of a child on its parent.
+	CircularDependency DiagnosticKind = "circular-dependency"
+	// ParentConflict is reported when a shared item cannot be placed after all
+	// of its parents because it is also an ancestor of one of them.
+	ParentConflict DiagnosticKind = "parent-conflict"
+	// ParentDependencyConflict is reported when an item depends on one of its
+	// own children, as children always come after their parent.
+	ParentDependencyConflict DiagnosticKind = "parent-dependency-conflict"
+)
+
+// Diagnostic is a single problem found by Validate. Related holds the ids of
+// the other items involved, for cycles it holds the full path.
+type Diagnostic struct {
+	Kind     DiagnosticKind
+	Severity Severity
+	ItemID   string
+	Related  []string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[%s] %s: %s", string(d.Severity), string(d.Kind), d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d Diagnostics) WithSeverity(severity Severity) Diagnostics {
+	result := Diagnostics{}
+	for _, diagnostic := range d {
+		if diagnostic.Severity == severity {
+			result = append(result, diagnostic)
+		}
+	}
+
+	return result
+}
+
+func (d Diagnostics) ForItem(id string) Diagnostics {
+	result := Diagnostics{}
+	for _, diagnostic := range d {
+		if diagnostic.ItemID == id {
+			result = append(result, diagnostic)
+		}
+	}
+
+	return result
+}
+
+// Validate checks the whole graph in one pass and returns every problem found,
+// without changing the service. Build will succeed when none of them is an error.
+func (d *DependencyTreeService[T]) Validate() Diagnostics {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := Diagnostics{}
+	for _, item := range d.flatTree {
+		parent, parentDiagnostic := d.validateParent(item)
+		if parentDiagnostic != nil {
+			result = append(result, *parentDiagnostic)
+		}
+		result = append(result, d.validateAdditionalParents(item)...)
+
+		// the dependency on the parent is added by Build, so it only counts
+		// as a duplicate if the item declares it twice
+		seen := make(map[*DependencyTreeItem[T]]bool)
+		for idx, dependency := range item.allDependencies() {
+			dependencyItem := d.getItem(dependency)
+			switch {
+			case dependencyItem == nil && idx >= len(item.IsDependentOn()):
+				result = append(result, Diagnostic{
+					Kind:     IgnoredOptionalDependency,
+					Severity: SeverityInfo,
+					ItemID:   item.ID,
+					Related:  []string{dependency},
+					Message:  fmt.Sprintf("optional dependency on %s of item %s was not found and will be ignored", dependency, item.ID),
+				})
+			case dependencyItem == nil:
+				result = append(result, Diagnostic{
+					Kind:     MissingDependency,
+					Severity: SeverityError,
+					ItemID:   item.ID,
+					Related:  []string{dependency},
+					Message:  fmt.Sprintf("dependency on %s of item %s was not found", dependency, item.ID),
+				})
+			case dependencyItem == item:
+				result = append(result, Diagnostic{
+					Kind:     SelfDependency,
+					Severity: SeverityError,
+					ItemID:   item.ID,
+					Related:  []string{item.ID},
+					Message:  fmt.Sprintf("item %s depends on itself", item.ID),
+				})
+			case seen[dependencyItem]:
+				result = append(result, Diagnostic{
+					Kind:     DuplicateDependency,
+					Severity: SeverityWarning,
+					ItemID:   item.ID,
+					Related:  []string{dependencyItem.ID},
+					Message:  fmt.Sprintf("item %s depends on %s more than once", item.ID, dependencyItem.ID),
+				})
+			case dependencyItem == parent:
+				seen[dependencyItem] = true
+			default:
+				seen[dependencyItem] = true
+
+				if d.isAncestor(item, dependencyItem) {
+					result = append(result, Diagnostic{
+						Kind:     ParentDependencyConflict,
+						Severity: SeverityError,
+						ItemID:   item.ID,
+						Related:  []string{dependencyItem.ID},
+						Message:  fmt.Sprintf("item %s depends on %s, but %s is one of its children and children always come after their parent", item.ID, dependencyItem.ID, dependencyItem.ID),
+					})
+				}
+			}
+		}
+
+		result = append(result, d.validateOrdering(item)...)
+	}
+
+	graph := d.newRelationsGraph()
+	placed := graph.placed()
+	for _, cycle := range graph.cycleError(placed).Cycles {
+		ids := make([]string, 0, len(cycle))
+		for _, cycleItem := range cycle {
+			ids = append(ids, cycleItem.ID)
+		}
+		result = append(result, Diagnostic{
+			Kind:     CircularDependency,
+			Severity: SeverityError,
+			ItemID:   cycle[0].ID,
+			Related:  ids,
+			Message:  fmt.Sprintf("circular dependency %s", strings.Join(ids, " -> ")),
+		})
+	}
+
+	// redundant dependencies can only be told apart once the cycles are fixed
+	if !slices.Contains(placed, false) {
+		for _, redundant := range d.redundantDependencies() {
+			ids := make([]string, 0, len(redundant.Path))
+			for _, pathItem := range redundant.Path {
+				ids = append(ids, pathItem.ID)
+			}
+			result = append(result, Diagnostic{
+				Kind:     RedundantDependency,
+				Severity: SeverityWarning,
+				ItemID:   redundant.Item.ID,
+				Related:  ids,
+				Message:  fmt.Sprintf("dependency on %s of item %s is redundant, it is already required through %s", redundant.Dependency, redundant.Item.ID, strings.Join(ids, " -> ")),
+			})
+		}
+	}
+
+	return result
+}
+
+// validateAdditionalParents checks the additional parents of the item and that
+// it can be placed after all of its parents.
+func (d *DependencyTreeService[T]) validateAdditionalParents(item *DependencyTreeItem[T]) Diagnostics {
+	result := Diagnostics{}
+	for _, parentName := range item.AdditionalParents() {
+		if d.getItem(parentName) == nil {
+			result = append(result, Diagnostic{
+				Kind:     MissingParent,
+				Severity: SeverityWarning,
+				ItemID:   item.ID,
+				Related:  []string{parentName},
+				Message:  fmt.Sprintf("additional parent %s of item %s was not found, it will be ignored", parentName, item.ID),
+			})
+		}
+	}
+
+	for _, parent := range d.parentConflicts(item) {
+		result = append(result, Diagnostic{
+			Kind:     ParentConflict,
+			Severity: SeverityError,
+			ItemID:   item.ID,
+			Related:  []string{parent.ID},
+			Message:  fmt.Sprintf("item %s cannot be placed after its parent %s, it is also an ancestor of %s", item.ID, parent.ID, parent.ID),
+		})
+	}
+
+	return result
+}
+
+// parentConflicts returns the parents of a shared item it cannot be placed
+// after, as the item is also one of their ancestors.
+func (d *DependencyTreeService[T]) parentConflicts(item *DependencyTreeItem[T]) []*DependencyTreeItem[T] {
+	result := []*DependencyTreeItem[T]{}
+	parents := d.itemParents(item)
+	if len(parents) < 2 {
+		return result
+	}
+
+	for _, parent := range parents {
+		if parent != item && d.isAncestor(item, parent) {
+			result = append(result, parent)
+		}
+	}
+
+	return result
+}
+
+// validateOrdering checks the After and Before hints of the item.
+func (d *DependencyTreeService[T]) validateOrdering(item *DependencyTreeItem[T]) Diagnostics {
+	result := Diagnostics{}
+	hints := []struct {
+		direction string
+		others    []string
+	}{
+		{"after", item.OrderedAfter()},
+		{"before", item.OrderedBefore()},
+	}
+	for _, hint := range hints {
+		direction := hint.direction
+		for _, other := range hint.others {
+			otherItem := d.getItem(other)
+			switch {
+			case otherItem == nil:
+				result = append(result, Diagnostic{
+					Kind:     IgnoredOrderingHint,
+					Severity: SeverityInfo,
+					ItemID:   item.ID,
+					Related:  []string{other},
+					Message:  fmt.Sprintf("item %s is ordered %s %s, which was not found, so the hint will be ignored", item.ID, direction, other),
+				})
+			case otherItem == item:
+				result = append(result, Diagnostic{
+					Kind:     SelfDependency,
+					Severity: SeverityError,
+					ItemID:   item.ID,
+					Related:  []string{item.ID},
+					Message:  fmt.Sprintf("item %s is ordered %s itself", item.ID, direction),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// validateParent resolves the parent of the item the same way Build does.
+func (d *DependencyTreeService[T]) validateParent(item *DependencyTreeItem[T]) (*DependencyTreeItem[T], *Diagnostic) {
+	parentName := item.GetParentName()
+	if parentName == "" || parentName == "root" {
+		return nil, nil
+	}
+
+	parent := d.getItem(parentName)
+	if parent == nil {
+		return nil, &Diagnostic{
+			Kind:     MissingParent,
+			Severity: SeverityWarning,
+			ItemID:   item.ID,
+			Related:  []string{parentName},
+			Message:  fmt.Sprintf("parent %s of item %s was not found, it will be treated as a root item", parentName, item.ID),
+		}
+	}
+
+	return parent, nil
+}
+
+// additionalParents resolves the additional parents of the item the same way
+// Build does, leaving out the ones that are missing, the item itself and its
+// parent.
+func (d *DependencyTreeService[T]) additionalParents(item *DependencyTreeItem[T]) []*DependencyTreeItem[T] {
+	result := []*DependencyTreeItem[T]{}
+	parent, _ := d.validateParent(item)
+	for _, parentName := range item.AdditionalParents() {
+		additional := d.getItem(parentName)
+		if additional == nil || additional == item || additional == parent || slices.Contains(result, additional) {
+			continue
+		}
+
+		result = append(result, additional)
+	}
+
+	return result
+}
+
+// itemParents returns the parent of the item followed by its additional parents.
+func (d *DependencyTreeService[T]) itemParents(item *DependencyTreeItem[T]) []*DependencyTreeItem[T] {
+	result := []*DependencyTreeItem[T]{}
+	if parent, _ := d.validateParent(item); parent != nil {
+		result = append(result, parent)
+	}
+
+	return append(result, d.additionalParents(item)...)
+}
+
+// isAncestor reports whether ancestor is one of the parents, grand parents and
+// so on of item.
+func (d *DependencyTreeService[T]) isAncestor(ancestor *DependencyTreeItem[T], item *DependencyTreeItem[T]) bool {
+	visited := map[*DependencyTreeItem[T]]bool{item: true}
+	queue := []*DependencyTreeItem[T]{item}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, parent := range d.itemParents(current) {
+			if parent == ancestor {
+				return true
+			}
+			if !visited[parent] {
+				visited[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,218 @@
+package dependencytree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("Additional parents", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("api", "api", MockObject1{id: "api"})
+		cache, _ := service.AddItem("cache", "cache", "api", MockObject1{id: "cache"})
+		_, _ = service.AddItem("jobs", "jobs", "cache", MockObject1{id: "jobs"})
+		_ = cache.AddParent("ghost")
+		_ = cache.AddParent("jobs")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 3)
+		assert.Equal(t, MissingParent, diagnostics[0].Kind)
+		assert.Equal(t, "additional parent ghost of item cache was not found, it will be ignored", diagnostics[0].Message)
+		assert.Equal(t, Diagnostic{
+			Kind:     ParentConflict,
+			Severity: SeverityError,
+			ItemID:   "cache",
+			Related:  []string{"jobs"},
+			Message:  "item cache cannot be placed after its parent jobs, it is also an ancestor of jobs",
+		}, diagnostics[1])
+		assert.Equal(t, CircularDependency, diagnostics[2].Kind)
+	})
+
+	t.Run("Ordering hints", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_ = service.After("a", "missing")
+		_ = service.Before("b", "b")
+		_ = service.After("b", "a")
+		_ = service.Before("b", "a")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 3)
+		assert.Equal(t, Diagnostic{
+			Kind:     IgnoredOrderingHint,
+			Severity: SeverityInfo,
+			ItemID:   "a",
+			Related:  []string{"missing"},
+			Message:  "item a is ordered after missing, which was not found, so the hint will be ignored",
+		}, diagnostics[0])
+		assert.Equal(t, SelfDependency, diagnostics[1].Kind)
+		assert.Equal(t, "item b is ordered before itself", diagnostics[1].Message)
+		assert.Equal(t, CircularDependency, diagnostics[2].Kind)
+		assert.Equal(t, []string{"a", "b", "a"}, diagnostics[2].Related)
+	})
+
+	t.Run("Missing optional dependency is only reported", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_ = service.DependsOnOptional("a", "b")
+		_ = service.DependsOnOptional("a", "missing")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, Diagnostic{
+			Kind:     IgnoredOptionalDependency,
+			Severity: SeverityInfo,
+			ItemID:   "a",
+			Related:  []string{"missing"},
+			Message:  "optional dependency on missing of item a was not found and will be ignored",
+		}, diagnostics[0])
+		assert.False(t, diagnostics.HasErrors())
+	})
+
+	t.Run("Redundant dependencies", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("a", "a", MockObject1{id: "a"})
+		_, _ = service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddRootItem("c", "c", MockObject1{id: "c"})
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("a", "c")
+		_ = service.DependsOn("b", "c")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, Diagnostic{
+			Kind:     RedundantDependency,
+			Severity: SeverityWarning,
+			ItemID:   "a",
+			Related:  []string{"a", "b", "c"},
+			Message:  "dependency on c of item a is redundant, it is already required through a -> b -> c",
+		}, diagnostics[0])
+		assert.False(t, diagnostics.HasErrors())
+
+		_ = service.DependsOn("c", "a")
+		diagnostics = service.Validate()
+
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, CircularDependency, diagnostics[0].Kind)
+	})
+
+	t.Run("Valid tree", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("item_1", "item 1", MockObject1{id: "item_1"})
+		_, _ = service.AddRootItem("item_2", "item 2", MockObject1{id: "item_2"})
+		_, _ = service.AddItem("item_2_child_1", "item 2 Child 1", "item_2", MockObject1{id: "item_2_child_1"})
+		_ = service.DependsOn("item_2", "item_1")
+		_ = service.DependsOn("item_2_child_1", "item_2")
+
+		diagnostics := service.Validate()
+
+		assert.Empty(t, diagnostics)
+		assert.False(t, diagnostics.HasErrors())
+
+		_, err := service.Build()
+		require.NoError(t, err)
+		assert.Empty(t, service.Validate())
+	})
+
+	t.Run("Reports every problem at once", func(t *testing.T) {
+		service := New[MockObject1]()
+		a, _ := service.AddRootItem("a", "a", MockObject1{id: "a"})
+		b, _ := service.AddRootItem("b", "b", MockObject1{id: "b"})
+		_, _ = service.AddItem("orphan", "orphan", "ghost", MockObject1{id: "orphan"})
+		_, _ = service.AddRootItem("parent", "parent", MockObject1{id: "parent"})
+		_, _ = service.AddItem("child", "child", "parent", MockObject1{id: "child"})
+		_, _ = service.AddRootItem("x", "x", MockObject1{id: "x"})
+		_, _ = service.AddRootItem("y", "y", MockObject1{id: "y"})
+		_ = a.DependsOn("missing")
+		_ = a.DependsOn("a")
+		_ = service.DependsOn("b", "x")
+		_ = b.DependsOn("missing")
+		_ = service.DependsOn("b", "x")
+		_ = service.DependsOn("parent", "child")
+		_ = service.DependsOn("x", "y")
+		_ = service.DependsOn("y", "x")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 8)
+		assert.Equal(t, Diagnostic{
+			Kind:     MissingDependency,
+			Severity: SeverityError,
+			ItemID:   "a",
+			Related:  []string{"missing"},
+			Message:  "dependency on missing of item a was not found",
+		}, diagnostics[0])
+		assert.Equal(t, SelfDependency, diagnostics[1].Kind)
+		assert.Equal(t, "a", diagnostics[1].ItemID)
+		assert.Equal(t, MissingDependency, diagnostics[2].Kind)
+		assert.Equal(t, "b", diagnostics[2].ItemID)
+		assert.Equal(t, []string{"missing"}, diagnostics[2].Related)
+		assert.Equal(t, DuplicateDependency, diagnostics[3].Kind)
+		assert.Equal(t, SeverityWarning, diagnostics[3].Severity)
+		assert.Equal(t, []string{"x"}, diagnostics[3].Related)
+		assert.Equal(t, MissingParent, diagnostics[4].Kind)
+		assert.Equal(t, "orphan", diagnostics[4].ItemID)
+		assert.Equal(t, ParentDependencyConflict, diagnostics[5].Kind)
+		assert.Equal(t, "parent", diagnostics[5].ItemID)
+		assert.Equal(t, []string{"child"}, diagnostics[5].Related)
+		assert.Equal(t, CircularDependency, diagnostics[6].Kind)
+		assert.Equal(t, []string{"parent", "child", "parent"}, diagnostics[6].Related)
+		assert.Equal(t, "[error] circular-dependency: circular dependency parent -> child -> parent", diagnostics[6].String())
+		assert.Equal(t, CircularDependency, diagnostics[7].Kind)
+		assert.Equal(t, []string{"x", "y", "x"}, diagnostics[7].Related)
+		assert.True(t, diagnostics.HasErrors())
+		assert.Len(t, diagnostics.WithSeverity(SeverityWarning), 2)
+		assert.Len(t, diagnostics.ForItem("a"), 2)
+	})
+
+	t.Run("Reports every cycle", func(t *testing.T) {
+		service := New[MockObject1]()
+		for _, id := range []string{"a", "b", "c", "d"} {
+			_, _ = service.AddRootItem(id, id, MockObject1{id: id})
+		}
+		_ = service.DependsOn("a", "b")
+		_ = service.DependsOn("b", "a")
+		_ = service.DependsOn("c", "d")
+		_ = service.DependsOn("d", "c")
+
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 2)
+		assert.Equal(t, []string{"a", "b", "a"}, diagnostics[0].Related)
+		assert.Equal(t, []string{"c", "d", "c"}, diagnostics[1].Related)
+	})
+
+	t.Run("Explicit dependency on the parent is not a duplicate", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("parent", "parent", MockObject1{id: "parent"})
+		child, _ := service.AddItem("child", "child", "parent", MockObject1{id: "child"})
+		_ = child.DependsOn("parent")
+
+		assert.Empty(t, service.Validate())
+
+		_ = service.DependsOn("child", "parent")
+		diagnostics := service.Validate()
+
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, DuplicateDependency, diagnostics[0].Kind)
+	})
+
+	t.Run("Does not change the service", func(t *testing.T) {
+		service := New[MockObject1]()
+		_, _ = service.AddRootItem("parent", "parent", MockObject1{id: "parent"})
+		child, _ := service.AddItem("child", "child", "parent", MockObject1{id: "child"})
+
+		_ = service.Validate()
+
+		assert.Nil(t, child.Parent)
+		assert.Empty(t, child.IsDependentOn())
+	})
+}
@@ -0,0 +1,202 @@
+package dependencytree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument is the YAML form of the TreeDocument, it uses the same fields:
+//
+//	version: 1
+//	items:
+//	  - id: api
+//	    name: API
+//	    parent: gateway
+//	    additionalParents: [admin]
+//	    dependsOn: [database]
+//	    optionalDependsOn: [cache]
+//	    after: [logging]
+//	    before: [metrics]
+//	    metadata:
+//	      timeout: 5s
+//	    value:
+//	      port: 8080
+//	edges:
+//	  - from: api
+//	    to: database
+//	    kind: depends-on
+//	    weight: 2
+type yamlDocument struct {
+	Version int        `yaml:"version"`
+	Items   []yamlItem `yaml:"items"`
+	Edges   Edges      `yaml:"edges"`
+}
+
+type yamlItem struct {
+	line     int
+	document ItemDocument
+	value    *yaml.Node
+}
+
+var yamlItemFields = map[string]bool{
+	"id":                true,
+	"name":              true,
+	"parent":            true,
+	"additionalParents": true,
+	"dependsOn":         true,
+	"optionalDependsOn": true,
+	"after":             true,
+	"before":            true,
+	"metadata":          true,
+	"value":             true,
+}
+
+func (i *yamlItem) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return &yamlError{line: node.Line, err: errors.New("item must be a mapping")}
+	}
+
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		key := node.Content[idx]
+		if !yamlItemFields[key.Value] {
+			return &yamlError{line: key.Line, err: fmt.Errorf("unknown item field %s", key.Value)}
+		}
+		if key.Value == "value" {
+			i.value = node.Content[idx+1]
+		}
+	}
+
+	fields := struct {
+		ID                string                 `yaml:"id"`
+		Name              string                 `yaml:"name"`
+		Parent            string                 `yaml:"parent"`
+		AdditionalParents []string               `yaml:"additionalParents"`
+		DependsOn         []string               `yaml:"dependsOn"`
+		OptionalDependsOn []string               `yaml:"optionalDependsOn"`
+		After             []string               `yaml:"after"`
+		Before            []string               `yaml:"before"`
+		Metadata          map[string]interface{} `yaml:"metadata"`
+	}{}
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+
+	i.line = node.Line
+	i.document = ItemDocument{
+		ID:                fields.ID,
+		Name:              fields.Name,
+		Parent:            fields.Parent,
+		AdditionalParents: fields.AdditionalParents,
+		DependsOn:         fields.DependsOn,
+		OptionalDependsOn: fields.OptionalDependsOn,
+		After:             fields.After,
+		Before:            fields.Before,
+		Metadata:          fields.Metadata,
+	}
+	return nil
+}
+
+// yamlError is an error found at a line of a YAML document.
+type yamlError struct {
+	line int
+	err  error
+}
+
+func (e *yamlError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.err.Error())
+}
+
+func (e *yamlError) Unwrap() error {
+	return e.err
+}
+
+// LoadYAMLFile replaces the items of the service with the ones declared in a
+// YAML file, errors are reported with the file name and the line of the item.
+func (d *DependencyTreeService[T]) LoadYAMLFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	if err := d.LoadYAML(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadYAML replaces the items of the service with the ones declared in a YAML
+// document, it uses the same fields as the JSON TreeDocument. The value of the
+// items is decoded into T with yaml.v3, unless a Codec was set with SetCodec
+// in which case the value is handed to the codec as JSON. Nothing is changed if
+// the document cannot be loaded.
+func (d *DependencyTreeService[T]) LoadYAML(data []byte) error {
+	document := yamlDocument{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if err := checkSchemaVersion(document.Version); err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	codec := d.codec
+	d.mu.RUnlock()
+
+	items := make([]*DependencyTreeItem[T], 0, len(document.Items))
+	for idx, yamlItem := range document.Items {
+		value, err := decodeYAMLValue(codec, yamlItem.value)
+		if err != nil {
+			return &yamlError{line: yamlItem.value.Line, err: fmt.Errorf("error decoding the value of item %s: %w", yamlItem.document.ID, err)}
+		}
+
+		item, err := newItemFromDocument(idx, yamlItem.document, value)
+		if err != nil {
+			return &yamlError{line: yamlItem.line, err: err}
+		}
+
+		items = append(items, item)
+	}
+
+	if idx, err := d.replaceItems(items, document.Edges); err != nil {
+		if idx < 0 {
+			return err
+		}
+		return &yamlError{line: document.Items[idx].line, err: err}
+	}
+
+	return nil
+}
+
+func decodeYAMLValue[T interface{}](codec Codec[T], node *yaml.Node) (T, error) {
+	var value T
+	if node == nil || node.Tag == "!!null" {
+		return value, nil
+	}
+
+	if codec == nil {
+		err := node.Decode(&value)
+		return value, err
+	}
+
+	var raw interface{}
+	if err := node.Decode(&raw); err != nil {
+		return value, err
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return value, err
+	}
+
+	return codec.Decode(data)
+}
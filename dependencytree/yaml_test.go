@@ -0,0 +1,169 @@
+package dependencytree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type yamlModule struct {
+	Port  int      `yaml:"port"`
+	Hosts []string `yaml:"hosts"`
+}
+
+const yamlTestDocument = `version: 1
+items:
+  - id: database
+    name: Database
+    value:
+      port: 5432
+      hosts: [primary, replica]
+  - id: api
+    name: API
+    dependsOn: [database]
+    metadata:
+      timeout: 5s
+      dot:
+        color: blue
+    value:
+      port: 8080
+  - id: handlers
+    name: Handlers
+    parent: api
+`
+
+func TestLoadYAML(t *testing.T) {
+	t.Run("Loads items, parents, dependencies, metadata and values", func(t *testing.T) {
+		service := New[yamlModule]()
+
+		err := service.LoadYAML([]byte(yamlTestDocument))
+		require.NoError(t, err)
+
+		items, err := service.Build()
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, "database", items[0].ID)
+		assert.Equal(t, yamlModule{Port: 5432, Hosts: []string{"primary", "replica"}}, items[0].Value())
+		assert.Equal(t, "api", items[1].ID)
+		assert.Equal(t, 8080, items[1].Value().Port)
+		assert.Equal(t, "5s", items[1].GetProperty(TimeoutProperty, nil))
+		assert.Equal(t, map[string]interface{}{"color": "blue"}, items[1].GetProperty(DOTAttributesProperty, nil))
+		assert.Equal(t, "handlers", items[2].ID)
+		assert.Equal(t, "api", items[2].GetParentId())
+		assert.Equal(t, yamlModule{}, items[2].Value())
+	})
+
+	t.Run("Loads shared items", func(t *testing.T) {
+		service := New[yamlModule]()
+
+		err := service.LoadYAML([]byte(`version: 1
+items:
+  - id: api
+    name: API
+  - id: worker
+    name: Worker
+  - id: cache
+    name: Cache
+    parent: api
+    additionalParents: [worker]
+`))
+		require.NoError(t, err)
+
+		items, err := service.Build()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker", "cache"}, []string{items[0].ID, items[1].ID, items[2].ID})
+		assert.Equal(t, []string{"worker"}, items[2].AdditionalParents())
+		assert.True(t, items[2].IsShared())
+	})
+
+	t.Run("Loads edge annotations", func(t *testing.T) {
+		service := New[yamlModule]()
+
+		err := service.LoadYAML([]byte(yamlTestDocument + `edges:
+  - from: api
+    to: database
+    kind: depends-on
+    weight: 1.5
+    metadata:
+      reason: stores sessions
+`))
+
+		require.NoError(t, err)
+		edges := service.Edges().WithKind(ExplicitEdge)
+		require.Len(t, edges, 1)
+		assert.Equal(t, 1.5, edges[0].Weight)
+		assert.Equal(t, "stores sessions", edges[0].Reason())
+	})
+
+	t.Run("Uses the codec of the service", func(t *testing.T) {
+		service := New[MockObject1]()
+		service.SetCodec(mockObject1Codec{})
+
+		err := service.LoadYAML([]byte("version: 1\nitems:\n  - id: a\n    name: a\n    value:\n      storedValue: stored\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "stored", service.GetItem("a").Value().someStoredValue)
+	})
+
+	t.Run("Reports errors with the line", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			document string
+			err      string
+		}{
+			{"empty document", "", "unsupported tree schema version 0, expected 1"},
+			{"invalid syntax", "version: 1\nitems: [\n", "yaml: line 2: did not find expected node content"},
+			{"unknown field", "version: 1\nitems:\n  - id: a\n    name: a\n    dependson: [b]\n", "line 5: unknown item field dependson"},
+			{"item is not a mapping", "version: 1\nitems:\n  - a\n", "line 3: item must be a mapping"},
+			{"missing name", "version: 1\nitems:\n  - id: a\n    name: a\n  - id: b\n", "line 5: item 1 is not valid: name must not be empty"},
+			{"duplicated id", "version: 1\nitems:\n  - id: a\n    name: a\n  - id: a\n    name: b\n", "line 5: item with id a already exists"},
+			{"invalid value", "version: 1\nitems:\n  - id: a\n    name: a\n    value:\n      port: http\n", "line 6: error decoding the value of item a: yaml: unmarshal errors:\n  line 6: cannot unmarshal !!str `http` into int"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				service := New[yamlModule]()
+				_, _ = service.AddRootItem("existing", "existing", yamlModule{})
+
+				err := service.LoadYAML([]byte(test.document))
+
+				assert.EqualError(t, err, test.err)
+				assert.NotNil(t, service.GetItem("existing"))
+			})
+		}
+	})
+}
+
+func TestLoadYAMLFile(t *testing.T) {
+	t.Run("Loads the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "services.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(yamlTestDocument), 0o600))
+		service := New[yamlModule]()
+
+		err := service.LoadYAMLFile(path)
+
+		require.NoError(t, err)
+		assert.Len(t, service.FlatTree(), 3)
+	})
+
+	t.Run("Reports errors with the file name and line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "services.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("version: 1\nitems:\n  - id: a\n"), 0o600))
+		service := New[yamlModule]()
+
+		err := service.LoadYAMLFile(path)
+
+		assert.EqualError(t, err, path+": line 3: item 0 is not valid: name must not be empty")
+	})
+
+	t.Run("Reports missing files", func(t *testing.T) {
+		service := New[yamlModule]()
+
+		err := service.LoadYAMLFile(filepath.Join(t.TempDir(), "missing.yaml"))
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}